@@ -200,13 +200,14 @@ type ShowRequest struct {
 }
 
 type ShowResponse struct {
-	License    string       `json:"license,omitempty"`
-	Modelfile  string       `json:"modelfile,omitempty"`
-	Parameters string       `json:"parameters,omitempty"`
-	Template   string       `json:"template,omitempty"`
-	System     string       `json:"system,omitempty"`
-	Details    ModelDetails `json:"details,omitempty"`
-	Messages   []Message    `json:"messages,omitempty"`
+	License     string       `json:"license,omitempty"`
+	Modelfile   string       `json:"modelfile,omitempty"`
+	Parameters  string       `json:"parameters,omitempty"`
+	Template    string       `json:"template,omitempty"`
+	System      string       `json:"system,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Details     ModelDetails `json:"details,omitempty"`
+	Messages    []Message    `json:"messages,omitempty"`
 }
 
 type CopyRequest struct {
@@ -248,12 +249,13 @@ type ListResponse struct {
 }
 
 type ModelResponse struct {
-	Name       string       `json:"name"`
-	Model      string       `json:"model"`
-	ModifiedAt time.Time    `json:"modified_at"`
-	Size       int64        `json:"size"`
-	Digest     string       `json:"digest"`
-	Details    ModelDetails `json:"details,omitempty"`
+	Name        string       `json:"name"`
+	Model       string       `json:"model"`
+	ModifiedAt  time.Time    `json:"modified_at"`
+	Size        int64        `json:"size"`
+	Digest      string       `json:"digest"`
+	Description string       `json:"description,omitempty"`
+	Details     ModelDetails `json:"details,omitempty"`
 }
 
 type TokenResponse struct {
@@ -62,7 +62,9 @@ func CreateHandler(cmd *cobra.Command, args []string) error {
 	}
 	defer f.Close()
 
-	modelfile, err := model.ParseFile(f)
+	// includes are resolved here, against the Modelfile's directory, as the
+	// server does not read them
+	modelfile, err := model.ParseFileWithOptions(f, model.ParseOptions{BaseDir: filepath.Dir(filename), AllowInclude: true})
 	if err != nil {
 		return err
 	}
@@ -80,6 +82,28 @@ func CreateHandler(cmd *cobra.Command, args []string) error {
 		switch modelfile.Commands[i].Name {
 		case "model", "adapter":
 			path := modelfile.Commands[i].Args
+
+			var adapter model.Adapter
+			if modelfile.Commands[i].Name == "adapter" {
+				// only the path of the adapter is uploaded, its alias and
+				// scale are sent with the digest
+				if adapter, err = model.ParseAdapter(path); err != nil {
+					return err
+				}
+
+				path = adapter.Path
+				if adapter.Inline != nil {
+					// inline adapters are uploaded like any other
+					tempfile, err := tempAdapterFile(adapter.Inline)
+					if err != nil {
+						return err
+					}
+					defer os.RemoveAll(tempfile)
+
+					path, adapter.Inline = tempfile, nil
+				}
+			}
+
 			if path == "~" {
 				path = home
 			} else if strings.HasPrefix(path, "~/") {
@@ -114,6 +138,30 @@ func CreateHandler(cmd *cobra.Command, args []string) error {
 				return err
 			}
 
+			modelfile.Commands[i].Args = "@" + digest
+			if modelfile.Commands[i].Name == "adapter" {
+				adapter.Path = "@" + digest
+				modelfile.Commands[i].Args = adapter.String()
+			}
+		case "tokenizer":
+			path, ok := strings.CutPrefix(modelfile.Commands[i].Args, "@")
+			if !ok {
+				// an inline override is sent as is
+				continue
+			} else if _, err := model.ParseDigest(path); err == nil {
+				// already uploaded
+				continue
+			}
+
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(filename), path)
+			}
+
+			digest, err := createBlob(cmd, client, path)
+			if err != nil {
+				return err
+			}
+
 			modelfile.Commands[i].Args = "@" + digest
 		}
 	}
@@ -260,6 +308,21 @@ func tempZipFiles(path string) (string, error) {
 	return tempfile.Name(), nil
 }
 
+// tempAdapterFile writes the data of an inline adapter to a temporary file.
+func tempAdapterFile(data []byte) (string, error) {
+	tempfile, err := os.CreateTemp("", "ollama-adapter")
+	if err != nil {
+		return "", err
+	}
+	defer tempfile.Close()
+
+	if _, err := tempfile.Write(data); err != nil {
+		return "", err
+	}
+
+	return tempfile.Name(), nil
+}
+
 func createBlob(cmd *cobra.Command, client *api.Client, path string) (string, error) {
 	bin, err := os.Open(path)
 	if err != nil {
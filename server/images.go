@@ -28,6 +28,7 @@ import (
 	"github.com/ollama/ollama/auth"
 	"github.com/ollama/ollama/convert"
 	"github.com/ollama/ollama/format"
+	"github.com/ollama/ollama/gpu"
 	"github.com/ollama/ollama/llm"
 	"github.com/ollama/ollama/types/errtypes"
 	"github.com/ollama/ollama/types/model"
@@ -51,6 +52,8 @@ type Model struct {
 	ProjectorPaths []string
 	Template       string
 	System         string
+	Description    string
+	Prefill        string
 	License        []string
 	Digest         string
 	Size           int64
@@ -70,6 +73,13 @@ func (m *Model) String() string {
 		Args: m.ModelPath,
 	})
 
+	if m.Description != "" {
+		modelfile.Commands = append(modelfile.Commands, model.Command{
+			Name: "description",
+			Args: m.Description,
+		})
+	}
+
 	if m.Template != "" {
 		modelfile.Commands = append(modelfile.Commands, model.Command{
 			Name: "template",
@@ -84,6 +94,13 @@ func (m *Model) String() string {
 		})
 	}
 
+	if m.Prefill != "" {
+		modelfile.Commands = append(modelfile.Commands, model.Command{
+			Name: "prefill",
+			Args: m.Prefill,
+		})
+	}
+
 	for _, adapter := range m.AdapterPaths {
 		modelfile.Commands = append(modelfile.Commands, model.Command{
 			Name: "adapter",
@@ -291,6 +308,20 @@ func GetModel(name string) (*Model, error) {
 			}
 
 			model.System = string(bts)
+		case "application/vnd.ollama.image.description":
+			bts, err := os.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+
+			model.Description = string(bts)
+		case "application/vnd.ollama.image.prefill":
+			bts, err := os.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+
+			model.Prefill = string(bts)
 		case "application/vnd.ollama.image.prompt":
 			bts, err := os.ReadFile(filename)
 			if err != nil {
@@ -356,6 +387,24 @@ func realpath(mfDir, from string) string {
 	return abspath
 }
 
+// createPlatform returns the platform constrained commands are evaluated
+// against when creating a model. Memory is only looked up when a command is
+// constrained by it.
+func createPlatform(cmds []model.Command) model.Platform {
+	p := model.Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+	for _, c := range cmds {
+		if slices.ContainsFunc(c.Constraints, func(c model.Constraint) bool { return c.Key == "mem" }) {
+			for _, g := range gpu.GetGPUInfo() {
+				p.Mem += g.TotalMemory
+			}
+
+			break
+		}
+	}
+
+	return p
+}
+
 func CreateModel(ctx context.Context, name, modelFileDir, quantization string, modelfile *model.File, fn func(resp api.ProgressResponse)) error {
 	deleteMap := make(map[string]struct{})
 	if manifest, _, err := GetManifest(ParseModelPath(name)); err == nil {
@@ -378,7 +427,25 @@ func CreateModel(ctx context.Context, name, modelFileDir, quantization string, m
 	params := make(map[string][]string)
 	fromParams := make(map[string]any)
 
-	for _, c := range modelfile.Commands {
+	cmds := modelfile.Commands
+	if i := slices.IndexFunc(cmds, func(c model.Command) bool { return c.Name == "variant" }); i >= 0 {
+		// commands from the first VARIANT on only override the base model
+		// for a named preset, see model.Variants; the base model is created
+		cmds = cmds[:i]
+	}
+
+	cmds = model.Select(cmds, createPlatform(cmds))
+	if !slices.ContainsFunc(cmds, func(c model.Command) bool { return c.Name == "model" }) {
+		return fmt.Errorf("no FROM applies on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+
+	// weights are only combined by external tools for now, so refuse before
+	// anything is pulled
+	if slices.ContainsFunc(cmds, func(c model.Command) bool { return c.Name == "merge" }) {
+		return errors.New("MERGE is not supported when creating a model, merge the weights first and use FROM")
+	}
+
+	for _, c := range cmds {
 		mediatype := fmt.Sprintf("application/vnd.ollama.image.%s", c.Name)
 
 		switch c.Name {
@@ -548,29 +615,51 @@ func CreateModel(ctx context.Context, name, modelFileDir, quantization string, m
 				offset += size
 			}
 		case "adapter":
-			if strings.HasPrefix(c.Args, "@") {
-				blobPath, err := GetBlobsPath(strings.TrimPrefix(c.Args, "@"))
+			// the alias only names the adapter within the Modelfile and is
+			// not part of its path
+			adapter, err := model.ParseAdapter(c.Args)
+			if err != nil {
+				return err
+			}
+
+			if adapter.Scale != 1 {
+				return errors.New("ADAPTER scale is not supported when creating a model, apply the adapter at full weight")
+			}
+
+			if strings.HasPrefix(adapter.Path, "@") {
+				blobPath, err := GetBlobsPath(strings.TrimPrefix(adapter.Path, "@"))
 				if err != nil {
 					return err
 				}
 
-				c.Args = blobPath
+				adapter.Path = blobPath
 			}
 
 			fn(api.ProgressResponse{Status: "creating adapter layer"})
-			bin, err := os.Open(realpath(modelFileDir, c.Args))
-			if err != nil {
-				return err
+
+			var bin io.ReadSeeker
+			if adapter.Inline != nil {
+				bin = bytes.NewReader(adapter.Inline)
+			} else {
+				f, err := os.Open(realpath(modelFileDir, adapter.Path))
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				bin = f
 			}
-			defer bin.Close()
 
 			_, size, err := llm.DecodeGGML(bin)
 			if err != nil {
 				return err
 			}
 
-			sr := io.NewSectionReader(bin, 0, size)
-			layer, err := NewLayer(sr, mediatype)
+			if _, err := bin.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+
+			layer, err := NewLayer(io.LimitReader(bin, size), mediatype)
 			if err != nil {
 				return err
 			}
@@ -586,7 +675,7 @@ func CreateModel(ctx context.Context, name, modelFileDir, quantization string, m
 			}
 
 			layers.Add(layer)
-		case "template", "system":
+		case "template", "system", "description", "prefill":
 			fn(api.ProgressResponse{Status: fmt.Sprintf("creating %s layer", c.Name)})
 
 			bin := strings.NewReader(c.Args)
@@ -595,9 +684,41 @@ func CreateModel(ctx context.Context, name, modelFileDir, quantization string, m
 				return err
 			}
 
+			layers.Replace(layer)
+		case "tokenizer":
+			fn(api.ProgressResponse{Status: "creating tokenizer layer"})
+
+			value := c.Args
+			if ref, ok := strings.CutPrefix(c.Args, "@"); ok {
+				// files are uploaded as blobs by the client, the server does
+				// not read paths from a Modelfile it was sent
+				if _, err := model.ParseDigest(ref); err != nil {
+					return fmt.Errorf("TOKENIZER file %s must be uploaded as a blob", ref)
+				}
+
+				path, err := GetBlobsPath(ref)
+				if err != nil {
+					return err
+				}
+
+				b, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+
+				value = string(b)
+			}
+
+			layer, err := NewLayer(strings.NewReader(value), mediatype)
+			if err != nil {
+				return err
+			}
+
 			layers.Replace(layer)
 		case "message":
 			messages = append(messages, c.Args)
+		case "annotation":
+			// annotations are metadata for clients and are not part of the model
 		default:
 			params[c.Name] = append(params[c.Name], c.Args)
 		}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/types/model"
+)
+
+// createGGUF writes an empty GGUF model to path.
+func createGGUF(t *testing.T, path string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, binary.Write(f, binary.LittleEndian, []byte("GGUF")))
+	assert.NoError(t, binary.Write(f, binary.LittleEndian, uint32(3)))
+	assert.NoError(t, binary.Write(f, binary.LittleEndian, uint64(0)))
+	assert.NoError(t, binary.Write(f, binary.LittleEndian, uint64(0)))
+	assert.NoError(t, f.Close())
+}
+
+func TestCreateModelDirectives(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	dir := t.TempDir()
+	createGGUF(t, filepath.Join(dir, "model.gguf"))
+
+	tokenizer := []byte(`{"version":"1.0"}`)
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(tokenizer))
+	blob, err := GetBlobsPath(digest)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(blob, tokenizer, 0o644))
+
+	modelfile, err := model.ParseFileWithOptions(strings.NewReader(`FROM ./model.gguf
+DESCRIPTION A test model.
+PREFILL """{"answer": """
+TOKENIZER @`+digest+`
+PARAMETER seed 42
+PARAMETER use_mmap yes
+VARIANT creative
+PARAMETER temperature 1.2
+`), model.ParseOptions{RequireBlobRefs: true})
+	assert.NoError(t, err)
+
+	assert.NoError(t, CreateModel(context.TODO(), "test", dir, "", modelfile, func(api.ProgressResponse) {}))
+
+	manifest, _, err := GetManifest(ParseModelPath("test"))
+	assert.NoError(t, err)
+
+	layers := make(map[string]string)
+	for _, layer := range manifest.Layers {
+		path, err := GetBlobsPath(layer.Digest)
+		assert.NoError(t, err)
+
+		b, err := os.ReadFile(path)
+		assert.NoError(t, err)
+
+		layers[strings.TrimPrefix(layer.MediaType, "application/vnd.ollama.image.")] = string(b)
+	}
+
+	assert.Equal(t, "A test model.", layers["description"])
+	assert.Equal(t, `{"answer": `, layers["prefill"])
+	assert.Equal(t, `{"version":"1.0"}`, layers["tokenizer"])
+	assert.JSONEq(t, `{"seed":42,"use_mmap":true}`, layers["params"])
+
+	// layers are read back when the model is shown
+	resp, err := GetModelInfo(api.ShowRequest{Model: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, "A test model.", resp.Description)
+	assert.Contains(t, resp.Modelfile, "\nDESCRIPTION A test model.\n")
+	assert.Contains(t, resp.Modelfile, "\nPREFILL \"\"\"{\"answer\": \"\"\"\n")
+
+	m, err := GetModel("test")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"answer": `, m.Prefill)
+}
+
+func TestCreateModelMerge(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	modelfile, err := model.ParseFile(strings.NewReader("FROM llama3\nMERGE llama3:0.5 mistral:0.5\n"))
+	assert.NoError(t, err)
+	assert.ErrorContains(t, CreateModel(context.TODO(), "test", "", "", modelfile, func(api.ProgressResponse) {}), "MERGE is not supported")
+}
+
+func TestCreateModelTokenizerPath(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	dir := t.TempDir()
+	createGGUF(t, filepath.Join(dir, "model.gguf"))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tokenizer.json"), []byte("{}"), 0o644))
+
+	modelfile, err := model.ParseFileWithOptions(strings.NewReader("FROM ./model.gguf\nTOKENIZER @tokenizer.json\n"), model.ParseOptions{BaseDir: dir})
+	assert.NoError(t, err)
+	assert.ErrorContains(t, CreateModel(context.TODO(), "test", dir, "", modelfile, func(api.ProgressResponse) {}), "must be uploaded as a blob")
+}
+
+func TestCreateModelAdapter(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	dir := t.TempDir()
+	createGGUF(t, filepath.Join(dir, "model.gguf"))
+	createGGUF(t, filepath.Join(dir, "lora.gguf"))
+
+	lora, err := os.ReadFile(filepath.Join(dir, "lora.gguf"))
+	assert.NoError(t, err)
+
+	var cases = []struct {
+		adapter string
+		err     string
+	}{
+		{"./lora.gguf", ""},
+		{"./lora.gguf 100%", ""},
+		{"name=lora ./lora.gguf", ""},
+		{"name=lora ./lora.gguf 1.0", ""},
+		{"base64:" + base64.StdEncoding.EncodeToString(lora), ""},
+		{"base64:" + base64.StdEncoding.EncodeToString([]byte("not a model")), "invalid file magic"},
+		{"./lora.gguf 80%", "ADAPTER scale is not supported"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.adapter, func(t *testing.T) {
+			modelfile, err := model.ParseFile(strings.NewReader("FROM ./model.gguf\nADAPTER " + c.adapter))
+			assert.NoError(t, err)
+
+			err = CreateModel(context.TODO(), "test", dir, "", modelfile, func(api.ProgressResponse) {})
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
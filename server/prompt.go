@@ -219,3 +219,15 @@ func ChatPrompt(tmpl string, messages []api.Message, window int, encode func(str
 
 	return sb.String(), nil
 }
+
+// prefillMessages appends an assistant message holding prefill to messages so
+// that the response continues from it. Requests which are empty, and so only
+// load the model, or which already end with an assistant message are returned
+// unchanged. It reports whether the message was added.
+func prefillMessages(messages []api.Message, prefill string) ([]api.Message, bool) {
+	if prefill == "" || len(messages) == 0 || messages[len(messages)-1].Role == "assistant" {
+		return messages, false
+	}
+
+	return append(messages, api.Message{Role: "assistant", Content: prefill}), true
+}
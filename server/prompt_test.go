@@ -1,6 +1,7 @@
 package server
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -203,3 +204,46 @@ func TestChatPrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestPrefillMessages(t *testing.T) {
+	user := api.Message{Role: "user", Content: "Hello"}
+	assistant := api.Message{Role: "assistant", Content: "Hi"}
+
+	tests := []struct {
+		name     string
+		messages []api.Message
+		prefill  string
+		want     []api.Message
+	}{
+		{"prefill", []api.Message{user}, `{"answer": `, []api.Message{user, {Role: "assistant", Content: `{"answer": `}}},
+		{"no prefill", []api.Message{user}, "", []api.Message{user}},
+		{"empty request", nil, `{"answer": `, nil},
+		{"final assistant message", []api.Message{user, assistant}, `{"answer": `, []api.Message{user, assistant}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := prefillMessages(tc.messages, tc.prefill)
+			if ok != (len(got) > len(tc.messages)) {
+				t.Errorf("ok = %v, got %d messages from %d", ok, len(got), len(tc.messages))
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+
+	// the response continues from the prefill
+	messages, _ := prefillMessages([]api.Message{user}, `{"answer": `)
+	got, err := ChatPrompt("[INST] {{ .Prompt }} [/INST] {{ .Response }}", messages, 1024, func(s string) ([]int, error) {
+		return make([]int, len(strings.Fields(s))), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `[INST] Hello [/INST] {"answer": `; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
@@ -553,7 +553,7 @@ func (s *Server) CreateModelHandler(c *gin.Context) {
 		r = f
 	}
 
-	modelfile, err := model.ParseFile(r)
+	modelfile, err := model.ParseFileWithOptions(r, model.ParseOptions{RequireBlobRefs: true})
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -690,11 +690,12 @@ func GetModelInfo(req api.ShowRequest) (*api.ShowResponse, error) {
 	}
 
 	resp := &api.ShowResponse{
-		License:  strings.Join(model.License, "\n"),
-		System:   model.System,
-		Template: model.Template,
-		Details:  modelDetails,
-		Messages: msgs,
+		License:     strings.Join(model.License, "\n"),
+		System:      model.System,
+		Template:    model.Template,
+		Description: model.Description,
+		Details:     modelDetails,
+		Messages:    msgs,
 	}
 
 	var params []string
@@ -750,11 +751,12 @@ func (s *Server) ListModelsHandler(c *gin.Context) {
 		}
 
 		return api.ModelResponse{
-			Model:   model.ShortName,
-			Name:    model.ShortName,
-			Size:    model.Size,
-			Digest:  model.Digest,
-			Details: modelDetails,
+			Model:       model.ShortName,
+			Name:        model.ShortName,
+			Size:        model.Size,
+			Digest:      model.Digest,
+			Description: model.Description,
+			Details:     modelDetails,
 		}, nil
 	}
 
@@ -1233,6 +1235,12 @@ func (s *Server) ChatHandler(c *gin.Context) {
 		}, req.Messages...)
 	}
 
+	// the model's PREFILL seeds the response, which is returned in full
+	var prefill string
+	if msgs, ok := prefillMessages(req.Messages, model.Prefill); ok {
+		req.Messages, prefill = msgs, model.Prefill
+	}
+
 	prompt, err := chatPrompt(c.Request.Context(), runner, model.Template, req.Messages, opts.NumCtx)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -1280,7 +1288,7 @@ func (s *Server) ChatHandler(c *gin.Context) {
 			resp := api.ChatResponse{
 				Model:     req.Model,
 				CreatedAt: time.Now().UTC(),
-				Message:   api.Message{Role: "assistant", Content: r.Content},
+				Message:   api.Message{Role: "assistant", Content: prefill + r.Content},
 				Done:      r.Done,
 				Metrics: api.Metrics{
 					PromptEvalCount:    r.PromptEvalCount,
@@ -1295,6 +1303,7 @@ func (s *Server) ChatHandler(c *gin.Context) {
 				resp.LoadDuration = checkpointLoaded.Sub(checkpointStart)
 			}
 
+			prefill = ""
 			ch <- resp
 		}
 
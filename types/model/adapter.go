@@ -0,0 +1,175 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	errNegativeScale         = errors.New("adapter scale must not be negative")
+	errInvalidInlineAdapter  = errors.New("invalid base64 in inline adapter")
+	errInlineAdapterTooLarge = errors.New("inline adapter exceeds maximum size")
+	errDuplicateAdapterName  = errors.New("duplicate adapter name")
+)
+
+// DefaultMaxInlineAdapterBytes is the default maximum decoded size of an
+// adapter embedded in a Modelfile with ADAPTER base64:<data>.
+const DefaultMaxInlineAdapterBytes = 1 << 20
+
+// Adapter is the structured form of an ADAPTER command.
+type Adapter struct {
+	// Name is the optional alias given with ADAPTER name=<alias> <path>.
+	Name string
+	Path string
+	// Inline holds the adapter data for adapters embedded in the Modelfile
+	// with ADAPTER base64:<data>, in which case Path is empty.
+	Inline []byte
+	// Scale is the weight the adapter is applied with. It defaults to 1.0.
+	Scale float64
+}
+
+// Adapters returns the adapters declared in cmds. An ADAPTER value may be
+// prefixed by an alias, name=<alias>, and followed by a scale, written either
+// as a float (0.8) or a percentage (80%). Aliases must be unique.
+func Adapters(cmds []Command) ([]Adapter, error) {
+	var adapters []Adapter
+	names := make(map[string]bool)
+	for _, cmd := range cmds {
+		if cmd.Name != "adapter" {
+			continue
+		}
+
+		adapter, err := ParseAdapter(cmd.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		if adapter.Name != "" {
+			if names[adapter.Name] {
+				return nil, fmt.Errorf("%w: %s", errDuplicateAdapterName, adapter.Name)
+			}
+
+			names[adapter.Name] = true
+		}
+
+		adapters = append(adapters, adapter)
+	}
+
+	return adapters, nil
+}
+
+// ParseAdapter parses the value of an ADAPTER command into its alias, path or
+// inline data and scale.
+func ParseAdapter(s string) (Adapter, error) {
+	var name string
+	if alias, ok := strings.CutPrefix(s, "name="); ok {
+		name, s, _ = strings.Cut(alias, " ")
+		if name == "" || strings.TrimSpace(s) == "" {
+			return Adapter{}, fmt.Errorf("invalid adapter alias %q", "name="+alias)
+		}
+
+		s = strings.TrimSpace(s)
+	}
+
+	adapter := Adapter{Name: name, Path: s, Scale: 1.0}
+
+	if i := strings.LastIndexAny(s, " \t"); i >= 0 {
+		path, scale := strings.TrimSpace(s[:i]), s[i+1:]
+		if pct, ok := strings.CutSuffix(scale, "%"); ok {
+			f, err := strconv.ParseFloat(pct, 64)
+			if err != nil {
+				return Adapter{}, fmt.Errorf("invalid adapter scale %q", scale)
+			}
+
+			adapter.Path, adapter.Scale = path, f/100
+		} else if f, err := strconv.ParseFloat(scale, 64); err == nil {
+			adapter.Path, adapter.Scale = path, f
+		}
+
+		if adapter.Scale < 0 {
+			return Adapter{}, fmt.Errorf("%w: %s", errNegativeScale, scale)
+		}
+	}
+
+	if data, ok := strings.CutPrefix(adapter.Path, "base64:"); ok {
+		inline, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return Adapter{}, fmt.Errorf("%w: %w", errInvalidInlineAdapter, err)
+		}
+
+		adapter.Path, adapter.Inline = "", inline
+	}
+
+	return adapter, nil
+}
+
+// String returns a as the value of an ADAPTER command. The scale is written
+// as a float and omitted when it is 1.0.
+func (a Adapter) String() string {
+	var sb strings.Builder
+	if a.Name != "" {
+		fmt.Fprintf(&sb, "name=%s ", a.Name)
+	}
+
+	if a.Inline != nil {
+		sb.WriteString("base64:" + base64.StdEncoding.EncodeToString(a.Inline))
+	} else {
+		sb.WriteString(a.Path)
+	}
+
+	if a.Scale != 1 {
+		sb.WriteString(" " + strconv.FormatFloat(a.Scale, 'f', -1, 64))
+	}
+
+	return sb.String()
+}
+
+// validateAdapter checks that an ADAPTER value is well formed and that any
+// inline adapter data does not exceed maxInline bytes.
+func validateAdapter(s string, maxInline int) (Adapter, error) {
+	if maxInline <= 0 {
+		maxInline = DefaultMaxInlineAdapterBytes
+	}
+
+	if data, ok := strings.CutPrefix(s, "base64:"); ok && base64.StdEncoding.DecodedLen(len(data)) > maxInline+2 {
+		return Adapter{}, errInlineAdapterTooLarge
+	}
+
+	adapter, err := ParseAdapter(s)
+	if err != nil {
+		return Adapter{}, err
+	}
+
+	if len(adapter.Inline) > maxInline {
+		return Adapter{}, errInlineAdapterTooLarge
+	}
+
+	return adapter, nil
+}
+
+// resolveAdapterPath rewrites a relative adapter path in the ADAPTER value s
+// to be relative to dir, keeping any alias and scale. Other values are
+// returned unchanged.
+func resolveAdapterPath(s, dir string) string {
+	adapter, err := ParseAdapter(s)
+	if err != nil || adapter.Inline != nil || dir == "." {
+		return s
+	}
+
+	path := adapter.Path
+	if filepath.IsAbs(path) || path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "@") {
+		return s
+	}
+
+	var off int
+	if adapter.Name != "" {
+		off = len("name=") + len(adapter.Name)
+	}
+
+	i := off + strings.Index(s[off:], path)
+	return s[:i] + filepath.Join(dir, path) + s[i+len(path):]
+}
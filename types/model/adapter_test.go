@@ -0,0 +1,179 @@
+package model
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdapters(t *testing.T) {
+	var cases = []struct {
+		args     string
+		expected Adapter
+		err      error
+	}{
+		{"lora.bin", Adapter{Path: "lora.bin", Scale: 1.0}, nil},
+		{"/path/to/my lora", Adapter{Path: "/path/to/my lora", Scale: 1.0}, nil},
+		{"lora.bin 0.5", Adapter{Path: "lora.bin", Scale: 0.5}, nil},
+		{"lora.bin 80%", Adapter{Path: "lora.bin", Scale: 0.8}, nil},
+		{"lora.bin 150%", Adapter{Path: "lora.bin", Scale: 1.5}, nil},
+		{"name=style lora.bin 80%", Adapter{Name: "style", Path: "lora.bin", Scale: 0.8}, nil},
+		{"lora.bin -20%", Adapter{}, errNegativeScale},
+		{"lora.bin -0.2", Adapter{}, errNegativeScale},
+	}
+
+	for _, c := range cases {
+		t.Run(c.args, func(t *testing.T) {
+			adapters, err := Adapters([]Command{{Name: "model", Args: "foo"}, {Name: "adapter", Args: c.args}})
+			assert.ErrorIs(t, err, c.err)
+			if err == nil {
+				assert.Equal(t, []Adapter{c.expected}, adapters)
+
+				adapter, err := ParseAdapter(c.expected.String())
+				assert.NoError(t, err)
+				assert.Equal(t, c.expected, adapter)
+			}
+		})
+	}
+
+	_, err := Adapters([]Command{{Name: "adapter", Args: "lora.bin lots%"}})
+	assert.EqualError(t, err, `invalid adapter scale "lots%"`)
+}
+
+func TestParseFileInlineAdapter(t *testing.T) {
+	var cases = []struct {
+		input    string
+		opts     ParseOptions
+		expected []Adapter
+		err      error
+	}{
+		{
+			"FROM foo\nADAPTER base64:aGVsbG8gbG9yYQ==",
+			ParseOptions{},
+			[]Adapter{{Inline: []byte("hello lora"), Scale: 1.0}},
+			nil,
+		},
+		{
+			"FROM foo\nADAPTER base64:aGVsbG8gbG9yYQ== 50%",
+			ParseOptions{},
+			[]Adapter{{Inline: []byte("hello lora"), Scale: 0.5}},
+			nil,
+		},
+		{
+			"FROM foo\nADAPTER base64:not*base64",
+			ParseOptions{},
+			nil,
+			errInvalidInlineAdapter,
+		},
+		{
+			"FROM foo\nADAPTER base64:aGVsbG8gbG9yYQ==",
+			ParseOptions{MaxInlineAdapterBytes: 8},
+			nil,
+			errInlineAdapterTooLarge,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), c.opts)
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				adapters, err := Adapters(modelfile.Commands)
+				assert.NoError(t, err)
+				assert.Equal(t, c.expected, adapters)
+			}
+		})
+	}
+}
+
+func TestParseFileAdapterNames(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []Adapter
+		err      error
+	}{
+		{
+			"FROM foo\nADAPTER name=mylora lora.bin",
+			[]Adapter{{Name: "mylora", Path: "lora.bin", Scale: 1.0}},
+			nil,
+		},
+		{
+			"FROM foo\nADAPTER name=mylora lora.bin 50%\nADAPTER other.bin",
+			[]Adapter{{Name: "mylora", Path: "lora.bin", Scale: 0.5}, {Path: "other.bin", Scale: 1.0}},
+			nil,
+		},
+		{
+			"FROM foo\nADAPTER name=mylora lora.bin\nADAPTER name=mylora other.bin",
+			nil,
+			errDuplicateAdapterName,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c.input))
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				adapters, err := Adapters(modelfile.Commands)
+				assert.NoError(t, err)
+				assert.Equal(t, c.expected, adapters)
+			}
+		})
+	}
+
+	_, err := Adapters([]Command{{Name: "adapter", Args: "name=a lora.bin"}, {Name: "adapter", Args: "name=a other.bin"}})
+	assert.ErrorIs(t, err, errDuplicateAdapterName)
+
+	_, err = Adapters([]Command{{Name: "adapter", Args: "name=a"}})
+	assert.EqualError(t, err, `invalid adapter alias "name=a"`)
+}
+
+func TestParseFileAdaptersRelativeToFrom(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []string
+	}{
+		{
+			"FROM ./models/llama/model.gguf\nADAPTER ./lora.gguf",
+			[]string{filepath.Join("models", "llama", "lora.gguf")},
+		},
+		{
+			"FROM /models/llama/model.gguf\nADAPTER name=lora lora.gguf 50%",
+			[]string{"name=lora " + filepath.Join("/models", "llama", "lora.gguf") + " 50%"},
+		},
+		{
+			"FROM /models/llama/model.gguf\nADAPTER /adapters/lora.gguf\nADAPTER @sha256:abc",
+			[]string{"/adapters/lora.gguf", "@sha256:abc"},
+		},
+		{
+			"FROM ./model.gguf\nADAPTER ./lora.gguf",
+			[]string{"./lora.gguf"},
+		},
+		{
+			"FROM llama3\nADAPTER ./lora.gguf",
+			[]string{"./lora.gguf"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), ParseOptions{AdaptersRelativeToFrom: true})
+			assert.NoError(t, err)
+
+			var adapters []string
+			for _, cmd := range modelfile.Commands {
+				if cmd.Name == "adapter" {
+					adapters = append(adapters, cmd.Args)
+				}
+			}
+
+			assert.Equal(t, c.expected, adapters)
+		})
+	}
+
+	modelfile, err := ParseFile(strings.NewReader("FROM ./models/llama/model.gguf\nADAPTER ./lora.gguf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "./lora.gguf", modelfile.Commands[1].Args)
+}
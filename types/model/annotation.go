@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errInvalidChecksum = errors.New("invalid @sha256 digest")
+
+// parseAnnotation parses a comment of the form "@key value" into its key and
+// value. Annotations attach metadata to a Modelfile without affecting the
+// model, e.g. "# @deprecated Use newmodel:latest instead".
+func parseAnnotation(comment string) (key, value string, ok bool) {
+	comment, ok = strings.CutPrefix(strings.TrimSpace(comment), "@")
+	if !ok {
+		return "", "", false
+	}
+
+	key, value, _ = strings.Cut(comment, " ")
+	if key == "" || strings.IndexFunc(key, func(r rune) bool {
+		return !isAlpha(r) && !isNumber(r) && r != '_' && r != '-'
+	}) >= 0 {
+		return "", "", false
+	}
+
+	return strings.ToLower(key), strings.TrimSpace(value), true
+}
+
+// Annotation returns the value of the last annotation named key in cmds.
+func Annotation(cmds []Command, key string) (string, bool) {
+	value, ok := "", false
+	for _, cmd := range cmds {
+		if cmd.Name != "annotation" {
+			continue
+		}
+
+		if k, v, _ := strings.Cut(cmd.Args, " "); k == key {
+			value, ok = v, true
+		}
+	}
+
+	return value, ok
+}
+
+// Deprecation returns the guidance given by a @deprecated annotation and
+// whether the Modelfile is deprecated.
+func Deprecation(cmds []Command) (string, bool) {
+	return Annotation(cmds, "deprecated")
+}
+
+// Checksum returns the digest declared by a @sha256 annotation, which tooling
+// can use to verify the Modelfile or the blobs it references. The digest is
+// written as 64 hex digits, optionally prefixed with sha256: or sha256-.
+func Checksum(cmds []Command) (Digest, bool, error) {
+	value, ok := Annotation(cmds, "sha256")
+	if !ok {
+		return Digest{}, false, nil
+	}
+
+	s := value
+	if !strings.HasPrefix(s, "sha256:") && !strings.HasPrefix(s, "sha256-") {
+		s = "sha256:" + s
+	}
+
+	d, err := ParseDigest(s)
+	if err != nil {
+		return Digest{}, true, fmt.Errorf("%w: %s", errInvalidChecksum, value)
+	}
+
+	return d, true, nil
+}
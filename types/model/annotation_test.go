@@ -0,0 +1,76 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileAnnotations(t *testing.T) {
+	input := `
+# @deprecated Use newmodel:latest instead
+# a regular comment
+#@Author someone
+# @ not an annotation
+FROM foo
+`
+
+	modelfile, err := ParseFile(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "annotation", Args: "deprecated Use newmodel:latest instead"},
+		{Name: "annotation", Args: "author someone"},
+		{Name: "model", Args: "foo"},
+	}, modelfile.Commands)
+
+	assert.Equal(t, "# @deprecated Use newmodel:latest instead\n# @author someone\nFROM foo\n", modelfile.String())
+
+	modelfile2, err := ParseFile(strings.NewReader(modelfile.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, modelfile.Commands, modelfile2.Commands)
+}
+
+func TestDeprecation(t *testing.T) {
+	modelfile, err := ParseFile(strings.NewReader("FROM foo\n# @deprecated Use newmodel:latest instead"))
+	assert.NoError(t, err)
+
+	guidance, ok := Deprecation(modelfile.Commands)
+	assert.True(t, ok)
+	assert.Equal(t, "Use newmodel:latest instead", guidance)
+
+	modelfile, err = ParseFile(strings.NewReader("# deprecated\nFROM foo"))
+	assert.NoError(t, err)
+
+	_, ok = Deprecation(modelfile.Commands)
+	assert.False(t, ok)
+}
+
+func TestChecksum(t *testing.T) {
+	sum := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	var cases = []struct {
+		input    string
+		expected string
+		ok       bool
+		err      error
+	}{
+		{"# @sha256 " + sum + "\nFROM foo", "sha256-" + sum, true, nil},
+		{"# @sha256 sha256:" + sum + "\nFROM foo", "sha256-" + sum, true, nil},
+		{"FROM foo", "", false, nil},
+		{"# @sha256 " + sum[:10] + "\nFROM foo", "", true, errInvalidChecksum},
+		{"# @sha256 md5:" + sum + "\nFROM foo", "", true, errInvalidChecksum},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c.input))
+			assert.NoError(t, err)
+
+			d, ok, err := Checksum(modelfile.Commands)
+			assert.ErrorIs(t, err, c.err)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.expected, d.String())
+		})
+	}
+}
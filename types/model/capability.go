@@ -0,0 +1,48 @@
+package model
+
+import "strings"
+
+// hasCapability reports whether cmds declare capability, either with a
+// CAPABILITY command, registered with ParseOptions.ExtraCommands, or with a
+// @capability annotation.
+func hasCapability(cmds []Command, capability string) bool {
+	for _, cmd := range cmds {
+		switch cmd.Name {
+		case "capability":
+			if strings.EqualFold(cmd.Args, capability) {
+				return true
+			}
+		case "annotation":
+			if key, value, _ := strings.Cut(cmd.Args, " "); key == "capability" && strings.EqualFold(value, capability) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsEmbeddingModel reports whether cmds describe an embedding model. A
+// CAPABILITY command, registered with ParseOptions.ExtraCommands, or a
+// @capability annotation declaring "embedding" is authoritative. Otherwise a
+// Modelfile without chat commands (TEMPLATE, SYSTEM or MESSAGE) whose base
+// model is named like an embedding model, e.g. nomic-embed-text, is assumed
+// to be one.
+func IsEmbeddingModel(cmds []Command) bool {
+	if hasCapability(cmds, "embedding") {
+		return true
+	}
+
+	var from string
+	var chat bool
+	for _, cmd := range cmds {
+		switch cmd.Name {
+		case "template", "system", "message":
+			chat = true
+		case "model":
+			from = cmd.Args
+		}
+	}
+
+	return !chat && strings.Contains(strings.ToLower(from), "embed")
+}
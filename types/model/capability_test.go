@@ -0,0 +1,34 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsEmbeddingModel(t *testing.T) {
+	opts := ParseOptions{ExtraCommands: map[string]CommandSpec{"capability": {Value: ValueSingleLine}}}
+
+	var cases = []struct {
+		input    string
+		expected bool
+	}{
+		{"FROM ./model.gguf\nCAPABILITY embedding", true},
+		{"# @capability embedding\nFROM ./model.gguf", true},
+		{"FROM nomic-embed-text\nPARAMETER num_ctx 8192", true},
+		{"FROM llama3\nTEMPLATE {{ .Prompt }}\nSYSTEM You are a file parser.\nMESSAGE user Hey there!", false},
+		{"FROM llama3\nCAPABILITY completion", false},
+		{"FROM llama3", false},
+		{"FROM nomic-embed-text\nSYSTEM You are a file parser.", false},
+		{"FROM ./model.gguf\nTEMPLATE {{ .Prompt }}\nCAPABILITY embedding", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), opts)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, IsEmbeddingModel(modelfile.Commands))
+		})
+	}
+}
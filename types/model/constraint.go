@@ -0,0 +1,317 @@
+package model
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errInvalidConstraint = errors.New("invalid constraint")
+
+// constraintKeys are the keys accepted in a command constraint and whether
+// their values are sizes which may be compared with <, <=, > and >=.
+var constraintKeys = map[string]bool{
+	"os":   false,
+	"arch": false,
+	"mem":  true,
+}
+
+// Constraint restricts when a command applies, e.g. the os=linux in
+// PARAMETER num_gpu 0 [os=linux] or the mem<8G in FROM model:q4_0 [mem<8G].
+// Constraints are not evaluated by the parser; callers decide which commands
+// apply.
+type Constraint struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+func (c Constraint) String() string {
+	return c.Key + c.Op + c.Value
+}
+
+// constraintRe matches the start of a constraint list: a key followed by an
+// operator. Requiring it means values like [INST] are not mistaken for
+// constraints.
+var constraintRe = regexp.MustCompile(`^\s*[a-z_]+\s*[=<>!~]`)
+
+// cutConstraints splits a trailing constraint list such as [os=linux] from s.
+// The list must be separated from the value by whitespace.
+func cutConstraints(s string) (value, constraints string, ok bool) {
+	if !strings.HasSuffix(s, "]") {
+		return s, "", false
+	}
+
+	i := strings.LastIndex(s, "[")
+	if i < 1 || !isSpace(rune(s[i-1])) || !constraintRe.MatchString(s[i+1:]) {
+		return s, "", false
+	}
+
+	return strings.TrimRight(s[:i], " \t"), s[i+1 : len(s)-1], true
+}
+
+// isConstraintList reports whether the trailing brackets s of the value of
+// cmd are a constraint list. FROM, ADAPTER and PARAMETER values take
+// constraints so any brackets which look like one are, and mistakes are
+// reported. Other values are free text, like the [a=b] in SYSTEM reply with
+// [a=b], so their brackets are only a constraint list when it is valid.
+func isConstraintList(cmd Command, s string) bool {
+	if cmd.Name == "model" || cmd.Name == "adapter" || cmd.isParameter() {
+		return true
+	}
+
+	_, err := parseConstraints(s)
+	return err == nil
+}
+
+// parseConstraints parses a comma separated list of constraints of the form
+// key op value.
+func parseConstraints(s string) ([]Constraint, error) {
+	var constraints []Constraint
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+
+		i := strings.IndexAny(field, "=<>!~")
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q", errInvalidConstraint, field)
+		}
+
+		j := i + strings.IndexFunc(field[i:], func(r rune) bool { return !strings.ContainsRune("=<>!~", r) })
+		if j < i {
+			j = len(field)
+		}
+
+		c := Constraint{
+			Key:   strings.TrimSpace(field[:i]),
+			Op:    field[i:j],
+			Value: strings.TrimSpace(field[j:]),
+		}
+
+		if c.Key == "" || c.Value == "" {
+			return nil, fmt.Errorf("%w: %q", errInvalidConstraint, field)
+		}
+
+		size, ok := constraintKeys[c.Key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown key %q", errInvalidConstraint, c.Key)
+		}
+
+		switch c.Op {
+		case "=", "!=":
+		case "<", "<=", ">", ">=":
+			if !size {
+				return nil, fmt.Errorf("%w: operator %q cannot be used with %s", errInvalidConstraint, c.Op, c.Key)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown operator %q", errInvalidConstraint, c.Op)
+		}
+
+		if size {
+			if _, err := parseSize(c.Value); err != nil {
+				return nil, fmt.Errorf("%w: %w", errInvalidConstraint, err)
+			}
+		}
+
+		constraints = append(constraints, c)
+	}
+
+	return constraints, nil
+}
+
+// parseSize parses a size such as 512M or 8G into bytes. Units are powers of
+// 1024 and may be followed by B or iB.
+func parseSize(s string) (uint64, error) {
+	n := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(s), "B"), "I")
+
+	var mul uint64 = 1
+	if i := len(n) - 1; i >= 0 {
+		switch n[i] {
+		case 'K':
+			mul = 1 << 10
+		case 'M':
+			mul = 1 << 20
+		case 'G':
+			mul = 1 << 30
+		case 'T':
+			mul = 1 << 40
+		}
+
+		if mul > 1 {
+			n = n[:i]
+		}
+	}
+
+	f, err := strconv.ParseFloat(n, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return uint64(f * float64(mul)), nil
+}
+
+// Platform is the machine constraints are evaluated against.
+type Platform struct {
+	// OS and Arch are compared with the os and arch keys, e.g. linux and
+	// arm64 as in runtime.GOOS and runtime.GOARCH.
+	OS   string
+	Arch string
+
+	// Mem is the memory available for models, in bytes, compared with the
+	// mem key.
+	Mem uint64
+}
+
+// Matches reports whether c holds on p. Constraints which do not parse never
+// hold.
+func (c Constraint) Matches(p Platform) bool {
+	var n int
+	switch c.Key {
+	case "os":
+		n = strings.Compare(p.OS, c.Value)
+	case "arch":
+		n = strings.Compare(p.Arch, c.Value)
+	case "mem":
+		size, err := parseSize(c.Value)
+		if err != nil {
+			return false
+		}
+
+		n = cmp.Compare(p.Mem, size)
+	default:
+		return false
+	}
+
+	switch c.Op {
+	case "=":
+		return n == 0
+	case "!=":
+		return n != 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	default:
+		return false
+	}
+}
+
+// Applies reports whether every constraint of c holds on p. Commands without
+// constraints always apply.
+func (c Command) Applies(p Platform) bool {
+	for _, constraint := range c.Constraints {
+		if !constraint.Matches(p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Equal reports whether c and o are the same command: the same name,
+// arguments, constraints and weight. Command holds its constraints in a slice
+// so it can't be compared with ==.
+func (c Command) Equal(o Command) bool {
+	if c.Name != o.Name || c.Args != o.Args || c.Weight != o.Weight || !slices.Equal(c.Constraints, o.Constraints) {
+		return false
+	}
+
+	if c.spec == nil || o.spec == nil {
+		return c.spec == o.spec
+	}
+
+	return c.spec.Value == o.spec.Value
+}
+
+// Select returns the commands of cmds which apply on p. A conditional FROM
+// which applies replaces any unconditional FROM, which then serves as the
+// default for other platforms.
+func Select(cmds []Command, p Platform) []Command {
+	override := slices.ContainsFunc(cmds, func(cmd Command) bool {
+		return cmd.Name == "model" && len(cmd.Constraints) > 0 && cmd.Applies(p)
+	})
+
+	var selected []Command
+	for _, cmd := range cmds {
+		if !cmd.Applies(p) || override && cmd.Name == "model" && len(cmd.Constraints) == 0 {
+			continue
+		}
+
+		selected = append(selected, cmd)
+	}
+
+	return selected
+}
+
+// exclusive reports whether no platform satisfies both a and b, that is
+// whether some key is constrained to disjoint values by each. The values
+// tried are those named by the two constraints, their neighbours and the
+// bounds, which covers every region the operators can carve out.
+func exclusive(a, b []Constraint) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.Key != y.Key || !disjoint(x, y) {
+				continue
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func disjoint(x, y Constraint) bool {
+	var probes []Platform
+	switch x.Key {
+	case "os":
+		for _, v := range []string{x.Value, y.Value, ""} {
+			probes = append(probes, Platform{OS: v})
+		}
+	case "arch":
+		for _, v := range []string{x.Value, y.Value, ""} {
+			probes = append(probes, Platform{Arch: v})
+		}
+	case "mem":
+		probes = append(probes, Platform{Mem: 0}, Platform{Mem: math.MaxUint64})
+		for _, c := range []Constraint{x, y} {
+			size, err := parseSize(c.Value)
+			if err != nil {
+				return false
+			}
+
+			probes = append(probes, Platform{Mem: size - 1}, Platform{Mem: size}, Platform{Mem: size + 1})
+		}
+	default:
+		return false
+	}
+
+	for _, p := range probes {
+		if x.Matches(p) && y.Matches(p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatConstraints(constraints []Constraint) string {
+	if len(constraints) == 0 {
+		return ""
+	}
+
+	s := make([]string, len(constraints))
+	for i, c := range constraints {
+		s[i] = c.String()
+	}
+
+	return " [" + strings.Join(s, ",") + "]"
+}
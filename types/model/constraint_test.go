@@ -0,0 +1,166 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileConditionalFrom(t *testing.T) {
+	input := `
+FROM model:q4_0 [mem<8G]
+FROM model:q8_0 [mem>=8G]
+`
+
+	modelfile, err := ParseFile(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "model:q4_0", Constraints: []Constraint{{Key: "mem", Op: "<", Value: "8G"}}},
+		{Name: "model", Args: "model:q8_0", Constraints: []Constraint{{Key: "mem", Op: ">=", Value: "8G"}}},
+	}, modelfile.Commands)
+
+	assert.Equal(t, "FROM model:q4_0 [mem<8G]\nFROM model:q8_0 [mem>=8G]\n", modelfile.String())
+
+	var cases = []string{
+		"FROM model:q4_0 [mem~8G]",
+		"FROM model:q4_0 [mem=>8G]",
+		"FROM model:q4_0 [mem<lots]",
+		"FROM model:q4_0 [os<linux]",
+	}
+
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			_, err := ParseFile(strings.NewReader(c))
+			assert.ErrorIs(t, err, errInvalidConstraint)
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	var cases = map[string]uint64{
+		"1024":  1024,
+		"512M":  512 << 20,
+		"8G":    8 << 30,
+		"8GB":   8 << 30,
+		"8GiB":  8 << 30,
+		"1.5G":  3 << 29,
+		"2t":    2 << 40,
+		"640kb": 640 << 10,
+	}
+
+	for k, v := range cases {
+		t.Run(k, func(t *testing.T) {
+			size, err := parseSize(k)
+			assert.NoError(t, err)
+			assert.Equal(t, v, size)
+		})
+	}
+
+	_, err := parseSize("-1G")
+	assert.Error(t, err)
+}
+
+func TestParseFileFreeTextBrackets(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected Command
+	}{
+		{"FROM foo\nSYSTEM reply with [a=b]", Command{Name: "system", Args: "reply with [a=b]"}},
+		{"FROM foo\nSYSTEM reply with [a=b] # note", Command{Name: "system", Args: "reply with [a=b]"}},
+		{"FROM foo\nTEMPLATE {{ .Prompt }} [format=json]", Command{Name: "template", Args: "{{ .Prompt }} [format=json]"}},
+		{"FROM foo\nSYSTEM reply with [os=linux]", Command{Name: "system", Args: "reply with", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c.input))
+			assert.NoError(t, err)
+			assert.Equal(t, []Command{{Name: "model", Args: "foo"}, c.expected}, modelfile.Commands)
+
+			modelfile2, err := ParseFile(strings.NewReader(modelfile.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, modelfile, modelfile2)
+		})
+	}
+}
+
+func TestCommandApplies(t *testing.T) {
+	p := Platform{OS: "linux", Arch: "amd64", Mem: 16 << 30}
+
+	var cases = []struct {
+		constraints string
+		expected    bool
+	}{
+		{"", true},
+		{"os=linux", true},
+		{"os=darwin", false},
+		{"os!=windows", true},
+		{"arch=arm64", false},
+		{"os=linux,arch=amd64", true},
+		{"os=linux,arch=arm64", false},
+		{"mem<8G", false},
+		{"mem>=8G", true},
+		{"mem<=16G", true},
+		{"mem>16G", false},
+		{"mem=16G", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.constraints, func(t *testing.T) {
+			var cmd Command
+			if c.constraints != "" {
+				var err error
+				cmd.Constraints, err = parseConstraints(c.constraints)
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, c.expected, cmd.Applies(p))
+		})
+	}
+}
+
+func TestCommandEqual(t *testing.T) {
+	cmd := Command{Name: "model", Args: "llama3", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}
+
+	var cases = []struct {
+		name     string
+		other    Command
+		expected bool
+	}{
+		{"same", Command{Name: "model", Args: "llama3", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}, true},
+		{"name", Command{Name: "adapter", Args: "llama3", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}, false},
+		{"args", Command{Name: "model", Args: "llama3:70b", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}, false},
+		{"constraints", Command{Name: "model", Args: "llama3", Constraints: []Constraint{{Key: "os", Op: "!=", Value: "linux"}}}, false},
+		{"no constraints", Command{Name: "model", Args: "llama3"}, false},
+		{"weight", Command{Name: "model", Args: "llama3", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}, Weight: 2}, false},
+		{"extra", Command{Name: "model", Args: "llama3", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}, spec: &CommandSpec{}}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, cmd.Equal(c.other))
+			assert.Equal(t, c.expected, c.other.Equal(cmd))
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	modelfile, err := ParseFile(strings.NewReader(`FROM llama3
+FROM llama3:q4_0 [mem<8G]
+PARAMETER num_gpu 0 [os=darwin]
+PARAMETER num_ctx 4096
+`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "num_ctx", Args: "4096"},
+	}, Select(modelfile.Commands, Platform{OS: "linux", Mem: 16 << 30}))
+
+	assert.Equal(t, []Command{
+		modelfile.Commands[1],
+		modelfile.Commands[2],
+		{Name: "num_ctx", Args: "4096"},
+	}, Select(modelfile.Commands, Platform{OS: "darwin", Mem: 4 << 30}))
+}
@@ -0,0 +1,18 @@
+package model
+
+import "errors"
+
+var errDuplicateDescription = errors.New("only one DESCRIPTION is allowed")
+
+// Description returns the DESCRIPTION declared in cmds, a summary of the
+// model for listings such as ollama list and ollama show. Unlike annotations
+// written as comments, it is a directive and may span lines as a """ block.
+func Description(cmds []Command) (string, bool) {
+	for _, cmd := range cmds {
+		if cmd.Name == "description" {
+			return cmd.Args, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescription(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected string
+		ok       bool
+		err      error
+	}{
+		{
+			"FROM llama3\nDESCRIPTION A helpful assistant.",
+			"A helpful assistant.",
+			true,
+			nil,
+		},
+		{
+			"FROM llama3\nDESCRIPTION \"\"\"\nA helpful assistant\nwhich answers \"briefly\".\n\"\"\"",
+			"\nA helpful assistant\nwhich answers \"briefly\".\n",
+			true,
+			nil,
+		},
+		{
+			"FROM llama3\n# @description not a directive",
+			"",
+			false,
+			nil,
+		},
+		{
+			"FROM llama3\nDESCRIPTION one\ndescription two",
+			"",
+			false,
+			errDuplicateDescription,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c.input))
+			assert.ErrorIs(t, err, c.err)
+			if err != nil {
+				return
+			}
+
+			description, ok := Description(modelfile.Commands)
+			assert.Equal(t, c.expected, description)
+			assert.Equal(t, c.ok, ok)
+		})
+	}
+}
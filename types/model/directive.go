@@ -0,0 +1,66 @@
+package model
+
+import "strings"
+
+// Directive is a display friendly form of a command. Kind is the keyword as
+// written in a Modelfile, e.g. FROM or PARAMETER, rather than the internal
+// command name.
+type Directive struct {
+	// Kind is the upper case keyword, e.g. FROM, PARAMETER or MESSAGE.
+	// Annotations have kind ANNOTATION and comments, kept by
+	// ParseWithComments, have kind COMMENT.
+	Kind string
+	// Name is the parameter name for PARAMETER, the role for MESSAGE and the
+	// key for ANNOTATION. It is empty for other kinds.
+	Name  string
+	Value string
+	// Line is the 1-based line the command starts on. For File.Directives it
+	// is the line in the source the file was parsed from, or 0 for commands
+	// inherited with INHERIT. Otherwise it is the line in the canonical form
+	// of cmds, as produced by File.String.
+	Line int
+}
+
+// Directives returns the directives of cmds in order. Commands do not record
+// where they were written, so lines are those of the canonical form of cmds;
+// use File.Directives for the lines of a parsed file.
+func Directives(cmds []Command) []Directive {
+	directives := make([]Directive, 0, len(cmds))
+
+	line := 1
+	for _, cmd := range cmds {
+		d := Directive{Kind: strings.ToUpper(cmd.Name), Value: cmd.Args, Line: line}
+		switch cmd.Name {
+		case "model":
+			d.Kind = "FROM"
+		case "message":
+			d.Name, d.Value, _ = strings.Cut(cmd.Args, ": ")
+		case "annotation":
+			d.Name, d.Value, _ = strings.Cut(cmd.Args, " ")
+		case "comment":
+			d.Kind = "COMMENT"
+		default:
+			if cmd.isParameter() {
+				d.Kind, d.Name = "PARAMETER", cmd.Name
+			}
+		}
+
+		directives = append(directives, d)
+		line += strings.Count(cmd.String(), "\n") + 1
+	}
+
+	return directives
+}
+
+// Directives returns the directives of f in order, with the source lines
+// they start on when f was parsed.
+func (f File) Directives() []Directive {
+	directives := Directives(f.Commands)
+	if len(f.lines) == len(directives) {
+		for i := range directives {
+			directives[i].Line = f.lines[i]
+		}
+	}
+
+	return directives
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectives(t *testing.T) {
+	input := `FROM llama3
+# @deprecated use llama3.1
+ADAPTER ./adapter.gguf
+TEMPLATE """{{ .System }}
+{{ .Prompt }}"""
+SYSTEM You are a file parser.
+PARAMETER stop <|eot_id|>
+PARAMETER temperature 0.7
+MESSAGE user Hey there!
+MESSAGE assistant Hello!
+LICENSE MIT
+`
+
+	modelfile, err := ParseFile(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Directive{
+		{Kind: "FROM", Value: "llama3", Line: 1},
+		{Kind: "ANNOTATION", Name: "deprecated", Value: "use llama3.1", Line: 2},
+		{Kind: "ADAPTER", Value: "./adapter.gguf", Line: 3},
+		{Kind: "TEMPLATE", Value: "{{ .System }}\n{{ .Prompt }}", Line: 4},
+		{Kind: "SYSTEM", Value: "You are a file parser.", Line: 6},
+		{Kind: "PARAMETER", Name: "stop", Value: "<|eot_id|>", Line: 7},
+		{Kind: "PARAMETER", Name: "temperature", Value: "0.7", Line: 8},
+		{Kind: "MESSAGE", Name: "user", Value: "Hey there!", Line: 9},
+		{Kind: "MESSAGE", Name: "assistant", Value: "Hello!", Line: 10},
+		{Kind: "LICENSE", Value: "MIT", Line: 11},
+	}, Directives(modelfile.Commands))
+}
+
+func TestFileDirectives(t *testing.T) {
+	input := `# sampling settings
+FROM llama3
+
+TEMPLATE """
+{{ .Prompt }}
+"""
+# keep answers short
+PARAMETER preset precise
+PARAMETER num_ctx 4096 # more memory
+`
+
+	modelfile, err := ParseFileWithOptions(strings.NewReader(input), ParseOptions{comments: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []Directive{
+		{Kind: "COMMENT", Value: "sampling settings", Line: 1},
+		{Kind: "FROM", Value: "llama3", Line: 2},
+		{Kind: "TEMPLATE", Value: "\n{{ .Prompt }}\n", Line: 4},
+		{Kind: "COMMENT", Value: "keep answers short", Line: 7},
+		{Kind: "PARAMETER", Name: "temperature", Value: "0.2", Line: 8},
+		{Kind: "PARAMETER", Name: "top_k", Value: "20", Line: 8},
+		{Kind: "PARAMETER", Name: "top_p", Value: "0.5", Line: 8},
+		{Kind: "PARAMETER", Name: "num_ctx", Value: "4096", Line: 9},
+		{Kind: "COMMENT", Value: "more memory", Line: 9},
+	}, modelfile.Directives())
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	errUndefinedVariable    = errors.New("undefined variable")
+	errUnterminatedVariable = errors.New("unterminated variable reference")
+)
+
+// ParseWithEnv is like ParseFile but expands ${VAR} references in values,
+// e.g. FROM ${BASE_MODEL}, from env. $$ is a literal $ and a $ followed by
+// anything else is kept as written. Command names and comments are not
+// expanded. A reference to a variable which is not in env is an error.
+func ParseWithEnv(r io.Reader, env map[string]string) ([]Command, error) {
+	if env == nil {
+		env = map[string]string{}
+	}
+
+	f, err := ParseFileWithOptions(r, ParseOptions{env: env})
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Commands, nil
+}
+
+// expandEnv replaces ${VAR} references in s with their values in env and $$
+// with $.
+func expandEnv(s string, env map[string]string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+
+		sb.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			sb.WriteByte('$')
+			s = s[i+2:]
+		case '{':
+			name, rest, ok := strings.Cut(s[i+2:], "}")
+			if !ok {
+				return "", fmt.Errorf("%w: %s", errUnterminatedVariable, s[i:])
+			}
+
+			value, ok := env[name]
+			if !ok {
+				return "", fmt.Errorf("%w: %s", errUndefinedVariable, name)
+			}
+
+			sb.WriteString(value)
+			s = rest
+		default:
+			sb.WriteByte('$')
+			s = s[i+1:]
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWithEnv(t *testing.T) {
+	env := map[string]string{"BASE_MODEL": "llama3:8b", "ADAPTER_PATH": "./lora.gguf", "TEMP": "0.7"}
+
+	var cases = []struct {
+		input    string
+		expected []Command
+		err      error
+	}{
+		{
+			"FROM ${BASE_MODEL}\nADAPTER ${ADAPTER_PATH}\nPARAMETER temperature ${TEMP}",
+			[]Command{
+				{Name: "model", Args: "llama3:8b"},
+				{Name: "adapter", Args: "./lora.gguf"},
+				{Name: "temperature", Args: "0.7"},
+			},
+			nil,
+		},
+		{
+			"FROM llama3\nSYSTEM \"\"\"It costs $$5 or ${TEMP}$ and $HOME.\"\"\"",
+			[]Command{
+				{Name: "model", Args: "llama3"},
+				{Name: "system", Args: "It costs $5 or 0.7$ and $HOME."},
+			},
+			nil,
+		},
+		{
+			"FROM ${MISSING_MODEL}",
+			nil,
+			errUndefinedVariable,
+		},
+		{
+			"FROM ${BASE_MODEL",
+			nil,
+			errUnterminatedVariable,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			cmds, err := ParseWithEnv(strings.NewReader(c.input), env)
+			assert.ErrorIs(t, err, c.err)
+			assert.Equal(t, c.expected, cmds)
+		})
+	}
+
+	_, err := ParseWithEnv(strings.NewReader("FROM llama3\nADAPTER ${ADAPTER}"), env)
+	assert.ErrorContains(t, err, "undefined variable: ADAPTER")
+
+	// without ParseWithEnv values are kept as written
+	modelfile, err := ParseFile(strings.NewReader("FROM llama3\nSYSTEM ${BASE_MODEL} $$"))
+	assert.NoError(t, err)
+	assert.Equal(t, "${BASE_MODEL} $$", modelfile.Commands[1].Args)
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"unsafe"
+)
+
+// ParseBytesFast parses a Modelfile held in memory. Simple single-line
+// commands are parsed without copying: the returned command names and values
+// may alias b, so b must not be modified while the result is in use. Inputs
+// which need quote, constraint, annotation or include processing fall back to
+// ParseFile and produce identical results.
+func ParseBytesFast(b []byte) (*File, error) {
+	if f, ok := parseBytesFast(b); ok {
+		return f, nil
+	}
+
+	return ParseFile(bytes.NewReader(b))
+}
+
+// parseBytesFast parses b if every line is a simple command or comment. It
+// reports false, without an error, for anything else so the caller can fall
+// back to the full parser which also produces the appropriate error.
+func parseBytesFast(b []byte) (*File, bool) {
+	if len(b) == 0 {
+		return nil, false
+	}
+
+	s := unsafe.String(&b[0], len(b))
+
+	var f File
+	f.Commands = make([]Command, 0, strings.Count(s, "\n")+1)
+	f.lines = make([]int, 0, cap(f.Commands))
+
+	var from, lf, crlf bool
+	for n := 1; len(s) > 0; n++ {
+		line, rest, newline := strings.Cut(s, "\n")
+		if newline {
+			if strings.HasSuffix(line, "\r") {
+				crlf = true
+			} else {
+				lf = true
+			}
+		}
+
+		s = rest
+		line = strings.TrimSuffix(line, "\r")
+
+		for i := 0; i < len(line); i++ {
+			if line[i] < ' ' || line[i] > '~' || line[i] == '"' || line[i] == '\'' || line[i] == '[' || line[i] == '\\' {
+				return nil, false
+			}
+		}
+
+		line = strings.TrimLeft(line, " ")
+		switch {
+		case line == "":
+			continue
+		case line[0] == '#':
+			if strings.Contains(line, "@") {
+				return nil, false
+			}
+
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, " ")
+		if !ok || value == "" || strings.Contains(value, " #") {
+			return nil, false
+		}
+
+		switch {
+		case strings.EqualFold(name, "from"):
+			if from || isValidCommand(value) {
+				return nil, false
+			}
+
+			name, from = "model", true
+		case strings.EqualFold(name, "parameter"):
+			if name, value, ok = strings.Cut(value, " "); !ok || name == "" || value == "" || !isParameterName(name) {
+				return nil, false
+			}
+
+			// presets, digit separators and invalid values need the full parser
+			if name == "preset" || strings.Contains(value, "_") || validateParameter(name, value) != nil {
+				return nil, false
+			}
+		case strings.EqualFold(name, "message"):
+			role, content, ok := strings.Cut(value, " ")
+			role = strings.ToLower(role)
+			if !ok || content == "" || !isValidMessageRole(role) {
+				return nil, false
+			}
+
+			name, value = "message", role+": "+content
+		case strings.EqualFold(name, "license"):
+			name = "license"
+		case strings.EqualFold(name, "template"):
+			name = "template"
+		case strings.EqualFold(name, "system"):
+			name = "system"
+		default:
+			return nil, false
+		}
+
+		f.Commands = append(f.Commands, Command{Name: name, Args: value})
+		f.lines = append(f.lines, n)
+	}
+
+	if lf && crlf {
+		f.Diagnostics = append(f.Diagnostics, diagMixedLineEndings)
+	}
+
+	return &f, from
+}
+
+func isParameterName(s string) bool {
+	for _, r := range s {
+		if !isAlpha(r) && !isNumber(r) && r != '_' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBytesFast(t *testing.T) {
+	var cases = []string{
+		"FROM foo",
+		"\n# comment\nFROM foo\r\nPARAMETER param1 value1\r\nPARAMETER stop ### User: \nTEMPLATE {{ .Prompt }}\nSYSTEM You are a file parser.\n",
+		"from foo\nparameter num_ctx 4096\nlicense MIT\nmessage user Hey there!\nMESSAGE assistant Hello!\n",
+		"FROM  foo\nPARAMETER stop  x\n",
+		"FROM foo\nMESSAGE System You are a file parser.\n",
+		// the remaining cases fall back to the full parser
+		"FROM foo\nSYSTEM \"\"\"\nThis is a\nmultiline system.\n\"\"\"\n",
+		"FROM foo\nPARAMETER stop \"### User: \"\n",
+		"FROM foo\nPARAMETER num_gpu 0 [os=linux]\n",
+		"# @deprecated Use bar instead\nFROM foo\n",
+		"FROM foo\nADAPTER adapter1 0.5\n",
+		"FROM foo\nSYSTEM\tYou are a file parser.\n",
+		"FROM foo\nPARAMETER param1",
+		"FROM foo\nMESSAGE badguy I'm a bad guy!",
+		"FROM foo\nPARAMETER num_ctx abc\n",
+		"FROM foo\nPARAMETER num_ctx 131_072\n",
+		"FROM foo\nPARAMETER preset precise\n",
+		"FROM foo\nFROM bar\n",
+		"FROM system",
+		"PARAMETER param1 value1",
+		"",
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			expected, expectedErr := ParseFile(strings.NewReader(c))
+			actual, err := ParseBytesFast([]byte(c))
+			assert.Equal(t, expectedErr, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestParseBytesFastAliases(t *testing.T) {
+	b := []byte("FROM foo\nSYSTEM You are a file parser.\n")
+
+	f, ok := parseBytesFast(b)
+	assert.True(t, ok)
+	assert.Equal(t, "You are a file parser.", f.Commands[1].Args)
+
+	copy(b[bytes.Index(b, []byte("file")):], "tool")
+	assert.Equal(t, "You are a tool parser.", f.Commands[1].Args)
+}
+
+func benchmarkModelfile() []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "FROM llama3:latest")
+	fmt.Fprintln(&b, "TEMPLATE {{ .System }} {{ .Prompt }}")
+	fmt.Fprintln(&b, "SYSTEM You are a file parser. Always parse things.")
+	for i := range 100 {
+		fmt.Fprintf(&b, "PARAMETER stop <|stop%d|>\n", i)
+		fmt.Fprintf(&b, "MESSAGE user Hey there, this is message %d!\n", i)
+	}
+
+	return b.Bytes()
+}
+
+func BenchmarkParseFile(b *testing.B) {
+	bts := benchmarkModelfile()
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := ParseFile(bytes.NewReader(bts)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseBytesFast(b *testing.B) {
+	bts := benchmarkModelfile()
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := ParseBytesFast(bts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
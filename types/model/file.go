@@ -3,15 +3,31 @@ package model
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type File struct {
 	Commands []Command
+
+	// Diagnostics are advisory findings made while parsing, such as mixed
+	// line endings, which do not prevent the file from being used.
+	Diagnostics []Diagnostic
+
+	// warnings are recorded when parsing for ParseWithWarnings.
+	warnings []Warning
+
+	// lines are the source lines Commands start on, 0 for commands inherited
+	// with INHERIT. They are nil unless the file was parsed.
+	lines []int
 }
 
 func (f File) String() string {
@@ -26,25 +42,68 @@ func (f File) String() string {
 type Command struct {
 	Name string
 	Args string
+
+	// Constraints restricts the platforms this command applies to.
+	Constraints []Constraint
+
+	// Weight is the importance of a MESSAGE used as a few-shot example,
+	// written MESSAGE user[weight=2]. Zero means the message is unweighted.
+	Weight float64
+
+	// spec is the spec of a command registered with
+	// ParseOptions.ExtraCommands. It is nil for built-in commands and for
+	// parameters, including those of ValueParameter commands.
+	spec *CommandSpec
+
+	// line is the source line the command starts on while it is parsed. It
+	// is moved to File.lines before the file is returned so that commands
+	// compare equal wherever they were written.
+	line int
 }
 
 func (c Command) String() string {
+	return c.format(quote)
+}
+
+// format formats c using quote to quote values which may span multiple lines.
+func (c Command) format(quote func(string) string) string {
 	var sb strings.Builder
 	switch c.Name {
 	case "model":
 		fmt.Fprintf(&sb, "FROM %s", c.Args)
-	case "license", "template", "system", "adapter":
-		fmt.Fprintf(&sb, "%s %s", strings.ToUpper(c.Name), quote(c.Args))
 	case "message":
-		role, message, _ := strings.Cut(c.Args, ": ")
-		fmt.Fprintf(&sb, "MESSAGE %s %s", role, quote(message))
+		fmt.Fprintf(&sb, "MESSAGE %s", c.formatMessage(quote))
+	case "annotation":
+		fmt.Fprintf(&sb, "# @%s", c.Args)
+	case "comment":
+		fmt.Fprintf(&sb, "# %s", c.Args)
 	default:
-		fmt.Fprintf(&sb, "PARAMETER %s %s", c.Name, quote(c.Args))
+		switch {
+		case c.spec != nil && c.spec.Value == ValueMessage:
+			fmt.Fprintf(&sb, "%s %s", strings.ToUpper(c.Name), c.formatMessage(quote))
+		case !c.isParameter():
+			fmt.Fprintf(&sb, "%s %s", strings.ToUpper(c.Name), quote(c.Args))
+		default:
+			fmt.Fprintf(&sb, "PARAMETER %s %s", c.Name, quote(formatParameter(c.Name, c.Args)))
+		}
 	}
 
+	sb.WriteString(formatConstraints(c.Constraints))
+
 	return sb.String()
 }
 
+// formatMessage formats the role, weight and content of a MESSAGE like
+// command.
+func (c Command) formatMessage(quote func(string) string) string {
+	role, message, _ := strings.Cut(c.Args, ": ")
+	if c.Weight != 0 {
+		role += "[weight=" + strconv.FormatFloat(c.Weight, 'f', -1, 64) + "]"
+	}
+
+	return role + " " + quote(message)
+}
+
 type state int
 
 const (
@@ -56,48 +115,866 @@ const (
 	stateComment
 )
 
+// Errors reported by the parser. They are usually wrapped in a *ParseError
+// so use errors.Is to test for them.
 var (
-	errMissingFrom        = errors.New("no FROM line")
-	errInvalidMessageRole = errors.New("message role must be one of \"system\", \"user\", or \"assistant\"")
-	errInvalidCommand     = errors.New("command must be one of \"from\", \"license\", \"template\", \"system\", \"adapter\", \"parameter\", or \"message\"")
+	ErrMissingFrom           = errors.New("no FROM line")
+	ErrMissingValue          = errors.New("missing value")
+	ErrInvalidRole           = errors.New("message role must be one of \"system\", \"user\", \"assistant\", or \"tool\"")
+	ErrUnterminatedQuote     = errors.New("unterminated quoted string")
+	ErrUnterminatedMultiline = errors.New("unterminated multiline value")
+)
+
+var (
+	errInvalidCommand   = errors.New("command must be one of " + directiveList())
+	errFromCommand      = errors.New("FROM must be followed by a model name, not a command")
+	errMultilineValue   = errors.New("value must be on a single line")
+	errMultipleFrom     = errors.New("multiple FROM lines found")
+	errNotStreamable    = errors.New("command depends on the whole file and cannot be streamed")
+	errValueTooLarge    = errors.New("value exceeds maximum size")
+	errInputTooLarge    = errors.New("input exceeds maximum size")
+	errDuplicateRef     = errors.New("file is referenced more than once")
+	errByteOrderMark    = errors.New("byte order mark is only allowed at the start of the file")
+	errUnexpectedHash   = errors.New("unexpected '#'; comments must start at the beginning of a line")
+	errTripleQuoteStyle = errors.New(`""" must be on its own line`)
+	errRawTab           = errors.New("unquoted value contains a tab; quote the value or remove the tab")
+)
+
+// ParseError is an error at a position in a Modelfile. Lines and columns
+// start at 1 and columns count runes.
+type ParseError struct {
+	Line int
+	Col  int
+	Msg  string
+
+	// Source is the text of the offending line. It is only set when parsing
+	// with ParseOptions.RetainLines.
+	Source string
+
+	err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Col, e.Msg)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
+
+// Render formats the error for display in the style of a compiler: the
+// error, the offending line and a caret under the column. Only the error is
+// rendered if the source line was not retained.
+func (e *ParseError) Render() string {
+	if e.Source == "" {
+		return e.Error()
+	}
+
+	// keep tabs so the caret lines up with the source
+	var caret strings.Builder
+	for i, r := range []rune(e.Source) {
+		if i >= e.Col-1 {
+			break
+		}
+
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+
+	for range e.Col - 1 - len([]rune(e.Source)) {
+		caret.WriteRune(' ')
+	}
+
+	caret.WriteRune('^')
+	return e.Error() + "\n" + e.Source + "\n" + caret.String()
+}
+
+// diagMixedLineEndings reports a file which uses both LF and CRLF line
+// endings.
+var diagMixedLineEndings = Diagnostic{
+	Severity: SeverityWarning,
+	Message:  "mixed line endings detected (LF and CRLF)",
+}
+
+// ValueKind describes how the value of a command is parsed.
+type ValueKind int
+
+const (
+	// ValueSingleLine is a value which must fit on a single line.
+	ValueSingleLine ValueKind = iota
+	// ValueMultiline is a value which may span multiple lines when quoted,
+	// like TEMPLATE or SYSTEM.
+	ValueMultiline
+	// ValueMessage is a role followed by content, like MESSAGE.
+	ValueMessage
+	// ValueParameter is a name followed by a value, like PARAMETER. The
+	// resulting command is named after the parameter.
+	ValueParameter
 )
 
+// CommandSpec describes a custom command.
+type CommandSpec struct {
+	Value ValueKind
+
+	// RequiresFrom requires a FROM command in any file using this command,
+	// even when ParseOptions.AllowNoFrom is set. It has no effect for
+	// ValueParameter commands, which are named after their parameter.
+	RequiresFrom bool
+}
+
+// ParseOptions configures ParseFileWithOptions.
+type ParseOptions struct {
+	// ExtraCommands registers additional commands, keyed by their lowercase
+	// name, which are accepted alongside the built-in commands.
+	ExtraCommands map[string]CommandSpec
+
+	// AllowNoFrom accepts fragments which do not contain a FROM command
+	// unless they use an extra command whose spec sets RequiresFrom.
+	AllowNoFrom bool
+
+	// ImplicitFrom treats a bare model reference on the first line which is
+	// not blank or a comment as a FROM command, e.g. "llama3".
+	ImplicitFrom bool
+
+	// MaxInlineAdapterBytes is the maximum decoded size of an adapter
+	// embedded with ADAPTER base64:<data>. Zero means
+	// DefaultMaxInlineAdapterBytes.
+	MaxInlineAdapterBytes int
+
+	// MaxValueBytes is the maximum size of a single value, such as a
+	// TEMPLATE block, and MaxInputBytes the maximum size of the whole input.
+	// They bound the memory used by untrusted Modelfiles, e.g. one with an
+	// unterminated triple quote. Comments are bounded only by MaxInputBytes.
+	// Zero means no limit.
+	MaxValueBytes int
+	MaxInputBytes int64
+
+	// BaseDir is the directory relative INCLUDE paths are resolved against.
+	// It should be the directory of the Modelfile being parsed, as ReadFile
+	// sets it; empty means the current directory. Nested includes resolve
+	// against the directory of the including file, and relative paths in
+	// included files are returned as absolute paths.
+	BaseDir string
+
+	// AllowInclude resolves INCLUDE by reading the named file from disk.
+	// Without it INCLUDE is rejected. It must not be set for Modelfiles from
+	// untrusted sources, such as those sent to the server, as it lets them
+	// read any file the process can.
+	AllowInclude bool
+
+	// RequireBlobRefs accepts only references to uploaded blobs, e.g.
+	// TOKENIZER @sha256:<digest>, rather than checking that a referenced file
+	// exists. It should be set for Modelfiles sent to the server, whose paths
+	// refer to the client's files.
+	RequireBlobRefs bool
+
+	// RejectRawTabsInValues rejects tabs inside unquoted values, which are
+	// usually pasted by accident. Tabs inside quoted values are allowed.
+	RejectRawTabsInValues bool
+
+	// RequireTripleQuotesOnOwnLines rejects """ values with content on the
+	// same line as the opening or closing """, e.g. TEMPLATE """{{ .Prompt }}
+	// rather than TEMPLATE """ followed by {{ .Prompt }} on the next line.
+	// By default both styles are accepted.
+	RequireTripleQuotesOnOwnLines bool
+
+	// CollapseBlankLines reduces runs of blank lines inside quoted multiline
+	// values, such as TEMPLATE blocks, to a single blank line.
+	CollapseBlankLines bool
+
+	// trivia records comments and blank lines as commands named "#" and "",
+	// inline comments as commands named "#inline" and keeps INCLUDE commands
+	// unexpanded so that Reformat can preserve them. Extra whitespace before
+	// unquoted values is dropped.
+	trivia bool
+
+	// InheritResolver returns the Modelfile stored for a registry reference,
+	// such as a prior version of the model. It is required to use INHERIT,
+	// which places the resolved Modelfile beneath the current file so that
+	// its commands are overridden, as with MergeCommands.
+	InheritResolver func(ref string) (io.Reader, error)
+
+	// inherits is the chain of references currently being inherited and is
+	// used to detect cycles and limit depth.
+	inherits []string
+
+	// recover, if set, is called with each error in the input, along with
+	// the last line discarded because of it, and parsing resumes at the next
+	// line instead of failing. Checks spanning the whole file are skipped.
+	recover func(err *ParseError, end int)
+
+	// ParameterValidator, if set, is called with the name and value of every
+	// parameter. An error fails the parse.
+	ParameterValidator func(name, value string) error
+
+	// RetainLines keeps the text of each line while parsing so that a
+	// *ParseError includes the offending line for ParseError.Render.
+	RetainLines bool
+
+	// Presets are the named parameter sets PARAMETER preset <name> expands
+	// into. Nil means DefaultPresets.
+	Presets map[string]map[string]string
+
+	// comments records comments as commands named "comment", including
+	// comments following an unquoted value.
+	comments bool
+
+	// RejectDuplicateRefs rejects files which reference the same local file
+	// in more than one FROM or ADAPTER command.
+	RejectDuplicateRefs bool
+
+	// CanonicalizeTags appends the default tag, latest, to FROM references
+	// to registry models which have no tag, as pulling them would.
+	CanonicalizeTags bool
+
+	// AdaptersRelativeToFrom resolves relative ADAPTER paths against the
+	// directory containing the preceding FROM when it is a local path, e.g.
+	// FROM ./llama/model.gguf and ADAPTER ./lora becomes ADAPTER llama/lora.
+	// When FROM is a registry model, or the option is unset, relative paths
+	// are left as written and so resolve against the Modelfile's directory,
+	// BaseDir. Absolute paths, paths beginning with ~, @ references and
+	// inline adapters are never rewritten.
+	AdaptersRelativeToFrom bool
+
+	// SortCanonical reorders the commands of File.Commands into a canonical
+	// sequence: FROM, PARAMETER, TEMPLATE, SYSTEM, ADAPTER, LICENSE and
+	// MESSAGE, followed by any other commands. Commands keep their relative
+	// order within each group, so the seeded conversation is unchanged, and
+	// commands scoped to a VARIANT are left in place after the shared ones.
+	// By default commands are returned in the order they are written.
+	SortCanonical bool
+
+	// includes is the chain of files currently being included and is used to
+	// detect cycles.
+	includes []string
+
+	// warnings records unknown parameter names for ParseWithWarnings.
+	warnings bool
+
+	// emit, if set, is called with each command as it completes instead of
+	// collecting the commands in File.Commands.
+	emit func(Command) error
+
+	// env, if set, holds the variables ${VAR} references in values are
+	// expanded from.
+	env map[string]string
+
+	// ctx, if set, stops parsing once it is done. It is checked at the start
+	// of each line and before resolving INCLUDE and INHERIT.
+	ctx context.Context
+}
+
+// ParseFile parses a Modelfile from r. A # preceded by whitespace starts an
+// inline comment, which is dropped, either after a closing quote or anywhere
+// in an unquoted value: SYSTEM You are #1 sets the system prompt to "You are",
+// so values containing such a # must be quoted.
 func ParseFile(r io.Reader) (*File, error) {
+	return ParseFileWithOptions(r, ParseOptions{})
+}
+
+// ParseWithComments is like ParseFile but keeps comments, in order, as
+// commands named "comment" so that they can be written back out. A # preceded
+// by whitespace in an unquoted value starts an inline comment, which ParseFile
+// drops and which here follows the command it is on.
+func ParseWithComments(r io.Reader) ([]Command, error) {
+	f, err := ParseFileWithOptions(r, ParseOptions{comments: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Commands, nil
+}
+
+// ParseStream is like ParseFile but passes each command to fn as it
+// completes instead of collecting them, so that large Modelfiles need not be
+// held in memory. Parsing stops at the first error from fn, which ParseStream
+// returns. Since ErrMissingFrom can only be reported at the end of r, fn may
+// already have been called for every command. PARAMETER preset and INHERIT
+// depend on the whole file and cannot be streamed.
+func ParseStream(r io.Reader, fn func(Command) error) error {
+	var stopped error
+	_, err := ParseFileWithOptions(r, ParseOptions{emit: func(cmd Command) error {
+		stopped = fn(cmd)
+		return stopped
+	}})
+	if stopped != nil {
+		return stopped
+	}
+
+	return err
+}
+
+// ParseContext is like ParseFileWithOptions but stops parsing when ctx is
+// done, returning ctx.Err(). It returns as soon as ctx is done, even while an
+// InheritResolver or a Read of r is still running, so that slow resolvers and
+// readers cannot hold up the caller. r is not read again once ParseContext
+// returns, other than to complete a Read already in progress, which closing r
+// interrupts: r is closed if it implements io.Closer. Otherwise r must not be
+// reused after ctx is done.
+func ParseContext(ctx context.Context, r io.Reader, opts ParseOptions) (*File, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	opts.ctx = ctx
+
+	type result struct {
+		f   *File
+		err error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		f, err := ParseFileWithOptions(ctxReader{ctx, r}, opts)
+		ch <- result{f, err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.f, res.err
+	case <-ctx.Done():
+		if c, ok := r.(io.Closer); ok {
+			c.Close()
+		}
+
+		return nil, ctx.Err()
+	}
+}
+
+// ctxReader fails reads once ctx is done so that a parse which has been
+// abandoned stops consuming the underlying reader.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
+
+// ParseWithTimeout parses untrusted input, giving up with
+// context.DeadlineExceeded if parsing takes longer than d.
+func ParseWithTimeout(r io.Reader, d time.Duration) ([]Command, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	f, err := ParseContext(ctx, r, ParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Commands, nil
+}
+
+// ReadFile parses the Modelfile at path, resolving INCLUDE against the
+// directory containing it. Errors are prefixed with path.
+func ReadFile(path string) ([]Command, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	modelfile, err := ParseFileWithOptions(f, ParseOptions{BaseDir: filepath.Dir(path), AllowInclude: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return modelfile.Commands, nil
+}
+
+// ParseFileWithOptions is like ParseFile but accepts options which extend or
+// restrict the accepted syntax.
+func ParseFileWithOptions(r io.Reader, opts ParseOptions) (*File, error) {
 	var cmd Command
 	var curr state
 	var b bytes.Buffer
 	var role string
+	var weight float64
+	var inherited []Command
+	line, col := 1, 0
+	var cmdLine, cmdCol int
+	// froms holds the lines of unconditional FROM commands outside of any
+	// VARIANT, of which there may only be one
+	var froms []int
+	var variant bool
+	var singleLine bool
+	adapterNames := make(map[string]bool)
+	refs := make(map[string]bool)
+
+	// fromDir is the directory of the most recent local FROM path and is
+	// empty when FROM is a registry model.
+	var fromDir string
 
 	var f File
+	var hasFrom bool
+	var requiresFrom string
+	// prefill and lastRole are used to check that PREFILL is not combined
+	// with a final assistant MESSAGE
+	var prefill bool
+	var lastRole string
+	var description bool
+
+	// add records cmds, passing them to opts.emit when streaming
+	add := func(cmds ...Command) error {
+		for _, cmd := range cmds {
+			if cmd.line == 0 {
+				// commands of included files start on the INCLUDE line
+				cmd.line = cmdLine
+			}
+
+			switch cmd.Name {
+			case "model":
+				hasFrom = true
+			case "prefill":
+				prefill = true
+			case "description":
+				if description {
+					return errDuplicateDescription
+				}
+
+				description = true
+			case "message":
+				lastRole, _, _ = strings.Cut(cmd.Args, ": ")
+			}
+
+			if spec, ok := opts.ExtraCommands[cmd.Name]; ok && spec.RequiresFrom && requiresFrom == "" {
+				requiresFrom = cmd.Name
+			}
+
+			if opts.emit == nil {
+				f.Commands = append(f.Commands, cmd)
+				continue
+			}
+
+			cmd.line = 0
+			if err := opts.emit(cmd); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	isCommand := func(s string) bool {
+		_, ok := opts.ExtraCommands[strings.ToLower(s)]
+		return ok || isValidCommand(s)
+	}
+
+	appendComment := func(s string) error {
+		if key, value, ok := parseAnnotation(s); ok {
+			return add(Command{Name: "annotation", Args: key + " " + value, line: line})
+		} else if opts.trivia {
+			return add(Command{Name: "#", Args: s, line: line})
+		} else if opts.comments {
+			return add(Command{Name: "comment", Args: strings.TrimSpace(s), line: line})
+		}
+
+		return nil
+	}
+
+	// appendCommand adds the command with value s. comment is the inline
+	// comment following a quoted value; those of unquoted values are cut
+	// from s here.
+	appendCommand := func(s, constraints, comment string, quoted bool) error {
+		if opts.RejectRawTabsInValues && !quoted && strings.Contains(s, "\t") {
+			return fmt.Errorf("%w: %s", errRawTab, cmd.Name)
+		}
+
+		if opts.RequireTripleQuotesOnOwnLines && strings.HasPrefix(b.String(), `"""`) && !tripleQuotesOnOwnLines(s) {
+			return fmt.Errorf("%w: %s", errTripleQuoteStyle, cmd.Name)
+		}
+
+		if opts.trivia && !quoted {
+			s = strings.TrimLeft(s, " \t")
+		}
+
+		if !quoted {
+			s, comment = cutInlineComment(s)
+			if value, c, ok := cutConstraints(s); ok && comment != "" && constraints == "" && isConstraintList(cmd, c) {
+				// constraints before an inline comment
+				s, constraints = value, c
+			}
+		}
+
+		if opts.env != nil {
+			var err error
+			if s, err = expandEnv(s, opts.env); err != nil {
+				return err
+			}
+		}
+
+		if cmd.isParameter() {
+			var err error
+			if s, err = normalizeParameter(cmd.Name, s); err != nil {
+				return err
+			}
+
+			if !opts.trivia {
+				if err := validateParameter(cmd.Name, s); err != nil {
+					return err
+				}
+			}
+
+			if _, ok := parameterKinds[cmd.Name]; opts.warnings && !ok && cmd.Name != "preset" {
+				f.warnings = append(f.warnings, Warning{
+					Message: fmt.Sprintf("unknown parameter %q", cmd.Name),
+					Line:    cmdLine,
+				})
+			}
+
+			if opts.ParameterValidator != nil {
+				if err := opts.ParameterValidator(cmd.Name, s); err != nil {
+					return fmt.Errorf("PARAMETER %s: %w", cmd.Name, err)
+				}
+			}
+		}
+
+		if opts.CollapseBlankLines && quoted {
+			s = collapseBlankLines(s)
+		}
+
+		cmd.Constraints = nil
+		if constraints != "" {
+			var err error
+			if cmd.Constraints, err = parseConstraints(constraints); err != nil {
+				return err
+			}
+		}
+
+		if cmd.Name == "model" && isCommand(s) {
+			return fmt.Errorf("%w: %s", errFromCommand, s)
+		}
+
+		if cmd.Name == "model" && opts.CanonicalizeTags {
+			s = canonicalizeTag(s)
+		}
+
+		if singleLine && strings.ContainsAny(s, "\r\n") {
+			return fmt.Errorf("%w: %s", errMultilineValue, cmd.Name)
+		}
+
+		if cmd.Name == "model" {
+			fromDir = ""
+			if isPathLike(s) {
+				fromDir = filepath.Dir(s)
+			}
+		}
+
+		if cmd.Name == "adapter" && opts.AdaptersRelativeToFrom && fromDir != "" {
+			s = resolveAdapterPath(s, fromDir)
+		}
+
+		if cmd.Name == "adapter" {
+			adapter, err := validateAdapter(s, opts.MaxInlineAdapterBytes)
+			if err != nil {
+				return err
+			}
+
+			if adapter.Name != "" {
+				if adapterNames[adapter.Name] {
+					return fmt.Errorf("%w: %s", errDuplicateAdapterName, adapter.Name)
+				}
+
+				adapterNames[adapter.Name] = true
+			}
+		}
+
+		if opts.RejectDuplicateRefs && (cmd.Name == "model" || cmd.Name == "adapter") {
+			if ref, ok := commandRef(Command{Name: cmd.Name, Args: s}); ok && isPathLike(ref) {
+				path, _ := localPath(ref, opts.BaseDir)
+				if refs[path] {
+					return fmt.Errorf("%w: %s", errDuplicateRef, ref)
+				}
+
+				refs[path] = true
+			}
+		}
+
+		if cmd.Name == "merge" {
+			if _, err := parseMerge(s); err != nil {
+				return err
+			}
+		}
+
+		if cmd.Name == "tokenizer" && strings.HasPrefix(s, "@") && !opts.trivia {
+			check := checkFileRef
+			if opts.RequireBlobRefs {
+				check = checkBlobRef
+			}
+
+			if err := check(s, opts.BaseDir); err != nil {
+				return err
+			}
+		}
+
+		if cmd.Name == "include" && !opts.trivia {
+			if !opts.AllowInclude {
+				return errIncludeNotAllowed
+			}
+
+			cmds, err := parseInclude(s, opts)
+			if err != nil {
+				return err
+			}
+
+			return add(cmds...)
+		}
+
+		if cmd.Name == "inherit" && opts.emit != nil {
+			return fmt.Errorf("%w: INHERIT", errNotStreamable)
+		} else if cmd.Name == "preset" && opts.emit != nil {
+			return fmt.Errorf("%w: PARAMETER preset", errNotStreamable)
+		}
+
+		if cmd.Name == "preset" && opts.recover != nil {
+			// reported here, rather than when presets are expanded, so that
+			// the line is skipped
+			presets := opts.Presets
+			if presets == nil {
+				presets = DefaultPresets
+			}
+
+			if _, ok := presets[s]; !ok {
+				return fmt.Errorf("%w: %s", errUnknownPreset, s)
+			}
+		}
+
+		if cmd.Name == "inherit" && !opts.trivia {
+			cmds, err := parseInherit(s, opts)
+			if err != nil {
+				return err
+			}
+
+			inherited = MergeCommands(inherited, cmds)
+			return nil
+		}
+
+		cmd.Weight = 0
+		if role != "" {
+			s = role + ": " + s
+			cmd.Weight = weight
+			role, weight = "", 0
+		}
+
+		switch {
+		case cmd.Name == "variant":
+			variant = true
+		case cmd.Name == "model" && len(cmd.Constraints) == 0 && !variant:
+			if len(froms) > 0 && opts.recover != nil {
+				// reported here so that only the later FROM is skipped
+				return fmt.Errorf("%w: lines %d and %d", errMultipleFrom, froms[0], cmdLine)
+			}
+
+			froms = append(froms, cmdLine)
+		}
+
+		cmd.Args = s
+		if err := add(cmd); err != nil {
+			return err
+		}
+
+		if comment != "" && opts.comments {
+			return add(Command{Name: "comment", Args: comment})
+		} else if comment != "" && opts.trivia {
+			return add(Command{Name: "#inline", Args: comment})
+		}
+
+		return nil
+	}
+
+	if opts.MaxInputBytes > 0 {
+		// read one byte past the limit to tell whether it was exceeded
+		r = io.LimitReader(r, opts.MaxInputBytes+1)
+	}
+
+	if opts.ImplicitFrom {
+		var err error
+		if r, err = implicitFrom(r); err != nil {
+			return nil, err
+		}
+	}
+
+	var prev rune
+	var read int64
+	var lf, crlf bool
+	// continued is set when a value was continued at a carriage return so
+	// the line feed that follows is dropped
+	var continued bool
+	// lines and text hold the completed lines and the current line when
+	// opts.RetainLines is set
+	var lines []string
+	var text strings.Builder
 
 	br := bufio.NewReader(r)
+
+	// failAt records the position of err, either that of the current
+	// command or of the current rune
+	failAt := func(line, col int, err error) error {
+		perr := &ParseError{Line: line, Col: col, Msg: err.Error(), err: err}
+		if opts.RetainLines && line >= 1 {
+			if line <= len(lines) {
+				perr.Source = lines[line-1]
+			} else {
+				rest, _ := br.ReadString('\n')
+				perr.Source = strings.TrimRight(text.String()+rest, "\r\n")
+			}
+		}
+
+		return perr
+	}
+
+	// skipping is set while the rest of a line is discarded after an error
+	var skipping bool
+
+	// resync passes err to opts.recover and resets the parser to resume at
+	// the next line. It reports false, when err should be returned instead.
+	resync := func(err error) bool {
+		var perr *ParseError
+		if opts.recover == nil || !errors.As(err, &perr) {
+			return false
+		}
+
+		opts.recover(perr, line)
+
+		b.Reset()
+		curr = stateNil
+		role, weight = "", 0
+		continued = false
+		skipping = !isNewline(prev)
+		return true
+	}
+
 	for {
-		r, _, err := br.ReadRune()
+		r, size, err := br.ReadRune()
 		if errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
 			return nil, err
 		}
 
+		if read += int64(size); opts.MaxInputBytes > 0 && read > opts.MaxInputBytes {
+			return nil, failAt(line, col, errInputTooLarge)
+		}
+
+		if prev == '\n' {
+			line, col = line+1, 0
+			if opts.ctx != nil && opts.ctx.Err() != nil {
+				return nil, opts.ctx.Err()
+			}
+		}
+
+		if r == '\uFEFF' && prev == 0 {
+			// skip the byte order mark written by some editors, which may
+			// only be the first rune
+			prev = r
+			continue
+		}
+
+		if opts.RetainLines {
+			if r == '\n' {
+				lines = append(lines, strings.TrimSuffix(text.String(), "\r"))
+				text.Reset()
+			} else {
+				text.WriteRune(r)
+			}
+		}
+
+		col++
+
+		if opts.trivia && curr == stateNil && isNewline(r) && !(r == '\n' && prev == '\r') {
+			// a newline outside of a command is a blank line
+			if err := add(Command{}); err != nil {
+				return nil, err
+			}
+		}
+
+		// lf follows cr in a \r\n line ending
+		lfAfterCR := r == '\n' && prev == '\r'
+		if r == '\n' {
+			if lfAfterCR {
+				crlf = true
+			} else {
+				lf = true
+			}
+		}
+
+		prev = r
+
+		if skipping {
+			skipping = !isNewline(r)
+			continue
+		}
+
+		if r == '\uFEFF' && (curr == stateNil || curr == stateName) {
+			if err := failAt(line, col, errByteOrderMark); !resync(err) {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if continued {
+			continued = false
+			if r == '\n' {
+				continue
+			}
+		}
+
 		next, r, err := parseRuneForState(r, curr)
-		if errors.Is(err, io.ErrUnexpectedEOF) {
-			return nil, fmt.Errorf("%w: %s", err, b.String())
+		if errors.Is(err, io.ErrUnexpectedEOF) && isNewline(prev) {
+			if err := failAt(line, col, fmt.Errorf("%w for %s: %w", ErrMissingValue, b.String(), err)); !resync(err) {
+				return nil, err
+			}
+
+			continue
+		} else if errors.Is(err, io.ErrUnexpectedEOF) {
+			if err := failAt(line, col, fmt.Errorf("%w: %s", err, b.String())); !resync(err) {
+				return nil, err
+			}
+
+			continue
 		} else if err != nil {
-			return nil, err
+			if err := failAt(line, col, err); !resync(err) {
+				return nil, err
+			}
+
+			continue
 		}
 
 		// process the state transition, some transitions need to be intercepted and redirected
 		if next != curr {
+			if curr == stateNil && next == stateName {
+				cmdLine, cmdCol = line, col
+			}
+
 			switch curr {
 			case stateName:
-				if !isValidCommand(b.String()) {
-					return nil, errInvalidCommand
+				if !isCommand(b.String()) {
+					if err := failAt(cmdLine, cmdCol, errInvalidCommand); !resync(err) {
+						return nil, err
+					}
+
+					continue
 				}
 
+				singleLine = false
+				cmd.spec = nil
+
 				// next state sometimes depends on the current buffer value
 				switch s := strings.ToLower(b.String()); s {
 				case "from":
 					cmd.Name = "model"
+				case "include", "inherit", "merge", "variant":
+					cmd.Name = s
+					singleLine = true
 				case "parameter":
 					// transition to stateParameter which sets command name
 					next = stateParameter
@@ -107,20 +984,70 @@ func ParseFile(r io.Reader) (*File, error) {
 					fallthrough
 				default:
 					cmd.Name = s
+
+					if spec, ok := opts.ExtraCommands[s]; ok {
+						if spec.Value != ValueParameter {
+							// only the value kind matters once parsed
+							cmd.spec = &CommandSpec{Value: spec.Value}
+						}
+
+						switch spec.Value {
+						case ValueSingleLine:
+							singleLine = true
+						case ValueMessage:
+							next = stateMessage
+						case ValueParameter:
+							next = stateParameter
+						}
+					}
 				}
 			case stateParameter:
 				cmd.Name = b.String()
 			case stateMessage:
-				if !isValidMessageRole(b.String()) {
-					return nil, errInvalidMessageRole
+				var err error
+				if role, weight, err = parseMessageRole(b.String()); err != nil {
+					if err := failAt(cmdLine, cmdCol, err); !resync(err) {
+						return nil, err
+					}
+
+					continue
 				}
+			case stateComment:
+				if err := appendComment(b.String()); err != nil {
+					if err := failAt(line, col, err); !resync(err) {
+						return nil, err
+					}
 
-				role = b.String()
-			case stateComment, stateNil:
+					continue
+				}
+			case stateNil:
 				// pass
 			case stateValue:
-				s, ok := unquote(b.String())
+				s, constraints, ok := unquoteValue(cmd, b.String())
+
+				var comment string
+				if !ok && isNewline(r) && isQuoted(b.String()) {
+					s, constraints, comment, ok = cutQuotedComment(cmd, b.String())
+				}
+
+				if !ok && isNewline(r) && strings.HasPrefix(b.String(), "'") {
+					// single quoted values cannot span lines
+					if err := failAt(cmdLine, cmdCol, fmt.Errorf("%w: %s", ErrUnterminatedQuote, b.String())); !resync(err) {
+						return nil, err
+					}
+
+					continue
+				}
+
 				if !ok || isSpace(r) {
+					// line endings in values are always stored as \n
+					switch {
+					case r == '\r':
+						r = '\n'
+					case lfAfterCR:
+						continue
+					}
+
 					if _, err := b.WriteRune(r); err != nil {
 						return nil, err
 					}
@@ -128,13 +1055,26 @@ func ParseFile(r io.Reader) (*File, error) {
 					continue
 				}
 
-				if role != "" {
-					s = role + ": " + s
-					role = ""
+				if !isQuoted(b.String()) && isNewline(r) {
+					if n := trailingBackslashes(s); n%2 == 1 {
+						// a trailing backslash continues the value on the next line
+						raw := b.String()
+						b.Reset()
+						b.WriteString(raw[:len(raw)-1])
+						continued = r == '\r'
+						continue
+					} else if n > 0 {
+						s = s[:len(s)-1]
+					}
 				}
 
-				cmd.Args = s
-				f.Commands = append(f.Commands, cmd)
+				if err := appendCommand(s, constraints, comment, isQuoted(b.String())); err != nil {
+					if err := failAt(cmdLine, cmdCol, err); !resync(err) {
+						return nil, err
+					}
+
+					continue
+				}
 			}
 
 			b.Reset()
@@ -146,35 +1086,152 @@ func ParseFile(r io.Reader) (*File, error) {
 				return nil, err
 			}
 		}
+
+		if opts.MaxValueBytes > 0 && b.Len() > opts.MaxValueBytes && curr != stateComment {
+			if err := failAt(cmdLine, cmdCol, errValueTooLarge); !resync(err) {
+				return nil, err
+			}
+
+			continue
+		}
 	}
 
 	// flush the buffer
 	switch curr {
-	case stateComment, stateNil:
+	case stateComment:
+		if err := appendComment(b.String()); err != nil {
+			if err := failAt(line, col, err); !resync(err) {
+				return nil, err
+			}
+		}
+	case stateNil:
 		// pass; nothing to flush
 	case stateValue:
-		s, ok := unquote(b.String())
-		if !ok {
-			return nil, io.ErrUnexpectedEOF
-		}
+		s, constraints, ok := unquoteValue(cmd, b.String())
 
-		if role != "" {
-			s = role + ": " + s
+		var comment string
+		if !ok && isQuoted(b.String()) {
+			s, constraints, comment, ok = cutQuotedComment(cmd, b.String())
 		}
 
-		cmd.Args = s
-		f.Commands = append(f.Commands, cmd)
+		switch {
+		case !ok && isQuoted(b.String()):
+			unterminated := ErrUnterminatedQuote
+			if strings.ContainsAny(b.String(), "\r\n") {
+				unterminated = ErrUnterminatedMultiline
+			}
+
+			if err := failAt(cmdLine, cmdCol, fmt.Errorf("%w: %w", unterminated, io.ErrUnexpectedEOF)); !resync(err) {
+				return nil, err
+			}
+		case !ok:
+			if err := failAt(line, col+1, fmt.Errorf("%w for %s: %w", ErrMissingValue, cmd.Name, io.ErrUnexpectedEOF)); !resync(err) {
+				return nil, err
+			}
+		default:
+			if n := trailingBackslashes(s); !isQuoted(b.String()) && n > 0 && n%2 == 0 {
+				s = s[:len(s)-1]
+			}
+
+			if err := appendCommand(s, constraints, comment, isQuoted(b.String())); err != nil {
+				if err := failAt(cmdLine, cmdCol, err); !resync(err) {
+					return nil, err
+				}
+			}
+		}
 	default:
-		return nil, io.ErrUnexpectedEOF
+		if err := failAt(line, col+1, fmt.Errorf("%w: %w", ErrMissingValue, io.ErrUnexpectedEOF)); !resync(err) {
+			return nil, err
+		}
 	}
 
-	for _, cmd := range f.Commands {
-		if cmd.Name == "model" {
-			return &f, nil
+	if len(opts.includes) == 0 && !opts.trivia {
+		// presets in included files are expanded by the including file
+		var err error
+		if f.Commands, err = expandPresets(f.Commands, opts.Presets); err != nil {
+			return nil, err
+		}
+	}
+
+	if inherited != nil {
+		f.Commands = MergeCommands(inherited, f.Commands)
+		for _, cmd := range inherited {
+			if cmd.Name == "model" {
+				hasFrom = true
+			}
+
+			if spec, ok := opts.ExtraCommands[cmd.Name]; ok && spec.RequiresFrom && requiresFrom == "" {
+				requiresFrom = cmd.Name
+			}
 		}
 	}
 
-	return nil, errMissingFrom
+	if lf && crlf {
+		f.Diagnostics = append(f.Diagnostics, diagMixedLineEndings)
+	}
+
+	// nested files are checked as part of the file including them
+	nested := len(opts.includes) > 0 || len(opts.inherits) > 0
+
+	if opts.SortCanonical && !nested && !opts.trivia {
+		f.Commands = sortCanonical(f.Commands)
+	}
+
+	f.lines = make([]int, len(f.Commands))
+	for i := range f.Commands {
+		f.lines[i], f.Commands[i].line = f.Commands[i].line, 0
+	}
+
+	if len(froms) > 1 && !opts.trivia {
+		return nil, failAt(froms[1], 1, fmt.Errorf("%w: lines %d and %d", errMultipleFrom, froms[0], froms[1]))
+	}
+
+	if prefill && lastRole == "assistant" && !nested && !opts.trivia && opts.recover == nil {
+		return nil, errPrefillConflict
+	}
+
+	if opts.AllowNoFrom && (requiresFrom == "" || nested) {
+		return &f, nil
+	}
+
+	if hasFrom {
+		return &f, nil
+	}
+
+	if requiresFrom != "" {
+		return nil, fmt.Errorf("%w: %s requires FROM", ErrMissingFrom, strings.ToUpper(requiresFrom))
+	}
+
+	return nil, ErrMissingFrom
+}
+
+// implicitFrom returns a reader which prefixes the first line which is not
+// blank or a comment with FROM if that line is a bare model reference.
+func implicitFrom(r io.Reader) (io.Reader, error) {
+	var consumed strings.Builder
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" && errors.Is(err, io.EOF):
+			consumed.WriteString(line)
+			return strings.NewReader(consumed.String()), nil
+		case trimmed == "", strings.HasPrefix(trimmed, "#"):
+			consumed.WriteString(line)
+			continue
+		case !strings.ContainsAny(trimmed, " \t") && !isValidCommand(trimmed):
+			consumed.WriteString("FROM ")
+		}
+
+		consumed.WriteString(line)
+		return io.MultiReader(strings.NewReader(consumed.String()), br), nil
+	}
 }
 
 func parseRuneForState(r rune, cs state) (state, rune, error) {
@@ -194,6 +1251,9 @@ func parseRuneForState(r rune, cs state) (state, rune, error) {
 			return stateName, r, nil
 		case isSpace(r):
 			return stateValue, 0, nil
+		case r == '#':
+			// a # only starts a comment before any command name
+			return stateNil, 0, errUnexpectedHash
 		default:
 			return stateNil, 0, errInvalidCommand
 		}
@@ -217,7 +1277,7 @@ func parseRuneForState(r rune, cs state) (state, rune, error) {
 		}
 	case stateMessage:
 		switch {
-		case isAlpha(r):
+		case isAlpha(r), isNumber(r), strings.ContainsRune("[]=._-", r):
 			return stateMessage, r, nil
 		case isSpace(r):
 			return stateValue, 0, nil
@@ -229,17 +1289,27 @@ func parseRuneForState(r rune, cs state) (state, rune, error) {
 		case isNewline(r):
 			return stateNil, 0, nil
 		default:
-			return stateComment, 0, nil
+			return stateComment, r, nil
 		}
 	default:
 		return stateNil, 0, errors.New("")
 	}
 }
 
+// quote quotes s if it would otherwise not survive parsing. Line endings are
+// parsed as \n, so a \r in s is read back as \n. Values containing """
+// immediately followed by a newline, or by a constraint list such as
+// [os=linux] at the end of a line, cannot be represented.
 func quote(s string) string {
-	if strings.Contains(s, "\n") || strings.HasPrefix(s, " ") || strings.HasSuffix(s, " ") {
+	if _, _, ok := cutConstraints(s); ok ||
+		s == "" ||
+		strings.ContainsAny(s, "\r\n") ||
+		strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'") ||
+		strings.Contains(s, " #") || strings.Contains(s, "\t#") ||
+		strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\t") ||
+		strings.HasSuffix(s, " ") || strings.HasSuffix(s, "\t") || strings.HasSuffix(s, `\`) {
 		if strings.Contains(s, "\"") {
-			return `"""` + s + `"""`
+			return `"""` + strings.ReplaceAll(s, `"""`, `\"""`) + `"""`
 		}
 
 		return `"` + s + `"`
@@ -248,20 +1318,131 @@ func quote(s string) string {
 	return s
 }
 
+// unquoteValue unquotes the value s of cmd, splitting off any trailing
+// constraint list.
+func unquoteValue(cmd Command, s string) (string, string, bool) {
+	if value, constraints, ok := cutConstraints(s); ok && isConstraintList(cmd, constraints) {
+		if value, ok := unquote(value); ok {
+			return value, constraints, true
+		}
+	}
+
+	value, ok := unquote(s)
+	return value, "", ok
+}
+
+// cutInlineComment splits an unquoted value at the first # preceded by
+// whitespace.
+func cutInlineComment(s string) (value, comment string) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && isSpace(rune(s[i-1])) {
+			return strings.TrimRight(s[:i], " \t"), strings.TrimSpace(s[i+1:])
+		}
+	}
+
+	return s, ""
+}
+
+// cutQuotedComment splits the raw value s of cmd, a quoted value followed by
+// an inline comment on the line of its closing quote, e.g. "a b" # c, into the
+// unquoted value, its constraints and the comment.
+func cutQuotedComment(cmd Command, s string) (value, constraints, comment string, ok bool) {
+	// the comment can only be on the last line, so only it is searched
+	for i := strings.LastIndexAny(s, "\r\n") + 1; i < len(s); i++ {
+		if i == 0 || s[i] != '#' || !isSpace(rune(s[i-1])) {
+			continue
+		}
+
+		quoted := strings.TrimRight(s[:i], " \t")
+		if _, _, ok := cutConstraints(quoted); !ok && closesAtEscape(quoted) {
+			continue
+		}
+
+		if value, constraints, ok := unquoteValue(cmd, quoted); ok {
+			return value, constraints, strings.TrimSpace(s[i+1:]), true
+		}
+	}
+
+	return "", "", "", false
+}
+
+// collapseBlankLines reduces runs of lines in s which are empty or only
+// whitespace to the first line of the run.
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	collapsed := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if i > 0 && strings.TrimSpace(line) == "" && strings.TrimSpace(lines[i-1]) == "" {
+			continue
+		}
+
+		collapsed = append(collapsed, line)
+	}
+
+	return strings.Join(collapsed, "\n")
+}
+
+// trailingBackslashes returns the number of backslashes at the end of s.
+func trailingBackslashes(s string) int {
+	return len(s) - len(strings.TrimRight(s, `\`))
+}
+
+// tripleQuotesOnOwnLines reports whether the opening and closing """ of a
+// block were each on a line of their own, as
+// ParseOptions.RequireTripleQuotesOnOwnLines requires. s is the unquoted
+// value, which then starts and ends with a newline once the spaces and tabs
+// beside the quotes are trimmed.
+func tripleQuotesOnOwnLines(s string) bool {
+	s = strings.Trim(s, " \t")
+	return strings.HasPrefix(s, "\n") && strings.HasSuffix(s, "\n")
+}
+
+// closesAtEscape reports whether the """ closing the raw triple-quoted value s
+// overlaps an escaped \""", as in """x\""" or """x\"""". Such a value is only
+// closed there at the end of the value, where the backslash is kept, and not
+// before an inline comment, which is then part of the value.
+func closesAtEscape(s string) bool {
+	body, ok := strings.CutPrefix(s, `"""`)
+	if !ok || len(body) < 3 {
+		return false
+	}
+
+	end := len(body) - 3
+	for i := 0; i < end; i++ {
+		if strings.HasPrefix(body[i:], `\"""`) {
+			if i+4 > end {
+				return true
+			}
+
+			i += 3
+		}
+	}
+
+	return false
+}
+
+// isQuoted reports whether the raw value s is quoted.
+func isQuoted(s string) bool {
+	return strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'")
+}
+
 func unquote(s string) (string, bool) {
 	if len(s) == 0 {
 		return "", false
 	}
 
-	// TODO: single quotes
-	if len(s) >= 3 && s[:3] == `"""` {
-		if len(s) >= 6 && s[len(s)-3:] == `"""` {
-			return s[3 : len(s)-3], true
+	if s[0] == '\'' {
+		if len(s) >= 2 && s[len(s)-1] == '\'' {
+			return s[1 : len(s)-1], true
 		}
 
 		return "", false
 	}
 
+	if len(s) >= 3 && s[:3] == `"""` {
+		return unquoteMultiline(s[3:])
+	}
+
 	if len(s) >= 1 && s[0] == '"' {
 		if len(s) >= 2 && s[len(s)-1] == '"' {
 			return s[1 : len(s)-1], true
@@ -273,6 +1454,31 @@ func unquote(s string) (string, bool) {
 	return s, true
 }
 
+// unquoteMultiline unquotes the rest of a triple-quoted value following the
+// opening """. The value must end with """ and \""" before it is an escaped
+// """. A backslash immediately before the closing """ is kept, so values
+// ending in a backslash, such as """C:\""", parse as they always have.
+func unquoteMultiline(s string) (string, bool) {
+	s, ok := strings.CutSuffix(s, `"""`)
+	if !ok {
+		return "", false
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], `\"""`) {
+			sb.WriteString(`"""`)
+			i += 4
+			continue
+		}
+
+		sb.WriteByte(s[i])
+		i++
+	}
+
+	return sb.String(), true
+}
+
 func isAlpha(r rune) bool {
 	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
 }
@@ -290,14 +1496,11 @@ func isNewline(r rune) bool {
 }
 
 func isValidMessageRole(role string) bool {
-	return role == "system" || role == "user" || role == "assistant"
+	return slices.Contains(messageRoles, role)
 }
 
 func isValidCommand(cmd string) bool {
-	switch strings.ToLower(cmd) {
-	case "from", "license", "template", "system", "adapter", "parameter", "message":
-		return true
-	default:
-		return false
-	}
+	return slices.ContainsFunc(directiveSpecs, func(spec DirectiveSpec) bool {
+		return strings.EqualFold(spec.Name, cmd)
+	})
 }
@@ -2,10 +2,16 @@ package model
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -69,18 +75,33 @@ func TestParseFileFrom(t *testing.T) {
 			nil,
 		},
 		{
-			"", nil, errMissingFrom,
+			"", nil, ErrMissingFrom,
 		},
 		{
 			"PARAMETER param1 value1",
 			nil,
-			errMissingFrom,
+			ErrMissingFrom,
 		},
 		{
 			"PARAMETER param1 value1\nFROM foo",
 			[]Command{{Name: "param1", Args: "value1"}, {Name: "model", Args: "foo"}},
 			nil,
 		},
+		{
+			"FROM PARAMETER",
+			nil,
+			errFromCommand,
+		},
+		{
+			"FROM system\nPARAMETER param1 value1",
+			nil,
+			errFromCommand,
+		},
+		{
+			"FROM parameters",
+			[]Command{{Name: "model", Args: "parameters"}},
+			nil,
+		},
 	}
 
 	for _, c := range cases {
@@ -103,9 +124,409 @@ PARAMETER param1
 	reader := strings.NewReader(input)
 
 	_, err := ParseFile(reader)
+	assert.ErrorIs(t, err, ErrMissingValue)
 	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
 }
 
+func TestParseFileCommandCase(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected Command
+	}{
+		{"FROM bar", Command{Name: "model", Args: "bar"}},
+		{"From bar", Command{Name: "model", Args: "bar"}},
+		{"from bar", Command{Name: "model", Args: "bar"}},
+		{"fRoM bar", Command{Name: "model", Args: "bar"}},
+		{"Adapter ./adapter.gguf", Command{Name: "adapter", Args: "./adapter.gguf"}},
+		{"adapter ./adapter.gguf", Command{Name: "adapter", Args: "./adapter.gguf"}},
+		{"License MIT", Command{Name: "license", Args: "MIT"}},
+		{"license MIT", Command{Name: "license", Args: "MIT"}},
+		{"Template {{ .Prompt }}", Command{Name: "template", Args: "{{ .Prompt }}"}},
+		{"template {{ .Prompt }}", Command{Name: "template", Args: "{{ .Prompt }}"}},
+		{"System You are a file parser.", Command{Name: "system", Args: "You are a file parser."}},
+		{"sYSTEM You are a file parser.", Command{Name: "system", Args: "You are a file parser."}},
+		{"Parameter temperature 0.7", Command{Name: "temperature", Args: "0.7"}},
+		{"parameter temperature 0.7", Command{Name: "temperature", Args: "0.7"}},
+		{"Message user Hey there!", Command{Name: "message", Args: "user: Hey there!"}},
+		{"message user Hey there!", Command{Name: "message", Args: "user: Hey there!"}},
+		{"Tokenizer ./tokenizer.json", Command{Name: "tokenizer", Args: "./tokenizer.json"}},
+		{"tokenizer ./tokenizer.json", Command{Name: "tokenizer", Args: "./tokenizer.json"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			input, expected := "FROM foo\n"+c.input, []Command{{Name: "model", Args: "foo"}, c.expected}
+			if c.expected.Name == "model" {
+				input, expected = c.input, []Command{c.expected}
+			}
+
+			modelfile, err := ParseFile(strings.NewReader(input))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, modelfile.Commands)
+
+			modelfile, err = ParseBytesFast([]byte(input))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, modelfile.Commands)
+		})
+	}
+}
+
+func TestParseFileMultipleFrom(t *testing.T) {
+	var cases = []struct {
+		input string
+		err   string
+	}{
+		{"FROM foo\nPARAMETER temperature 0.7\nFROM bar\n", "line 3, column 1: multiple FROM lines found: lines 1 and 3"},
+		{"FROM foo\nFROM foo\n", "line 2, column 1: multiple FROM lines found: lines 1 and 2"},
+		{"FROM foo\nFROM bar [os=darwin]\n", ""},
+		{"FROM foo\nVARIANT small\nFROM foo:1b\n", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			_, err := ParseFile(strings.NewReader(c.input))
+			if c.err != "" {
+				assert.ErrorIs(t, err, errMultipleFrom)
+				assert.EqualError(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseFileInlineComments(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []Command
+	}{
+		{
+			"PARAMETER temperature 0.8 # creative",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "temperature", Args: "0.8"}},
+		},
+		{
+			"PARAMETER temperature 0.8\t# creative\nSYSTEM You are a file parser.   # be brief",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "temperature", Args: "0.8"}, {Name: "system", Args: "You are a file parser."}},
+		},
+		{
+			"PARAMETER num_ctx 4096 [os=linux] # more memory",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "num_ctx", Args: "4096", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}},
+		},
+		{
+			"PARAMETER stop ### User:",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: "### User:"}},
+		},
+		{
+			"PARAMETER stop a#b",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: "a#b"}},
+		},
+		{
+			`PARAMETER stop "a # b"`,
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: "a # b"}},
+		},
+		{
+			"SYSTEM \"\"\"You are\na # file parser.\"\"\"",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "You are\na # file parser."}},
+		},
+		{
+			// an unquoted # after whitespace always starts a comment
+			"SYSTEM You are #1 helper",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "You are"}},
+		},
+		{
+			"SYSTEM \"a b\" # c\nPARAMETER temperature 0.8",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "a b"}, {Name: "temperature", Args: "0.8"}},
+		},
+		{
+			"SYSTEM 'a b'\t# c # d",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "a b"}},
+		},
+		{
+			"SYSTEM \"\"\"\nx # y\n\"\"\" # c",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "\nx # y\n"}},
+		},
+		{
+			// an escaped """ does not close the value before a comment
+			"SYSTEM \"\"\"x\\\"\"\" # y\nz\\\"\"\"\" # w\n\"\"\"",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "x\"\"\" # y\nz\"\"\"\" # w\n"}},
+		},
+		{
+			"PARAMETER num_ctx \"4096\" [os=linux] # more memory",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "num_ctx", Args: "4096", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader("FROM foo\n" + c.input))
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, modelfile.Commands)
+
+			modelfile, err = ParseBytesFast([]byte("FROM foo\n" + c.input))
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, modelfile.Commands)
+		})
+	}
+}
+
+func TestParseFileBOM(t *testing.T) {
+	modelfile, err := ParseFile(strings.NewReader("\uFEFFFROM foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{{Name: "model", Args: "foo"}}, modelfile.Commands)
+
+	_, err = ParseFile(strings.NewReader("\uFEFF\uFEFFFROM foo"))
+	assert.ErrorIs(t, err, errByteOrderMark)
+
+	_, err = ParseFile(strings.NewReader("FROM foo\n\uFEFFPARAMETER temperature 0.7"))
+	assert.ErrorIs(t, err, errByteOrderMark)
+	assert.EqualError(t, err, "line 2, column 1: byte order mark is only allowed at the start of the file")
+}
+
+func TestCommandString(t *testing.T) {
+	var cases = []struct {
+		cmd      Command
+		expected string
+	}{
+		{Command{Name: "model", Args: "llama3"}, "FROM llama3"},
+		{Command{Name: "temperature", Args: "0.7"}, "PARAMETER temperature 0.7"},
+		{Command{Name: "message", Args: "user: Hey there!", Weight: 2}, "MESSAGE user[weight=2] Hey there!"},
+		{Command{Name: "system", Args: "You are\na file parser."}, "SYSTEM \"You are\na file parser.\""},
+		{Command{Name: "system", Args: "a\rb"}, "SYSTEM \"a\rb\""},
+		{Command{Name: "num_ctx", Args: "4096", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}, "PARAMETER num_ctx 4096 [os=linux]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expected, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.cmd.String())
+			assert.Equal(t, c.expected, fmt.Sprintf("%s", c.cmd))
+		})
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	input := `FROM foo
+# @author someone
+PARAMETER temperature 0.7
+SYSTEM """You are
+a file parser."""
+MESSAGE user Hey there!
+`
+
+	var cmds []Command
+	err := ParseStream(strings.NewReader(input), func(cmd Command) error {
+		cmds = append(cmds, cmd)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	modelfile, err := ParseFile(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, modelfile.Commands, cmds)
+
+	// fn stops the parse early
+	errStop := errors.New("stop")
+	var names []string
+	err = ParseStream(strings.NewReader(input+"BADCOMMAND x\n"), func(cmd Command) error {
+		names = append(names, cmd.Name)
+		if cmd.Name == "temperature" {
+			return errStop
+		}
+
+		return nil
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, []string{"model", "annotation", "temperature"}, names)
+
+	// FROM is checked at the end of the stream
+	names = nil
+	err = ParseStream(strings.NewReader("PARAMETER temperature 0.7\nSYSTEM You are a file parser.\n"), func(cmd Command) error {
+		names = append(names, cmd.Name)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrMissingFrom)
+	assert.Equal(t, []string{"temperature", "system"}, names)
+
+	err = ParseStream(strings.NewReader("FROM foo\nPARAMETER preset creative\n"), func(Command) error { return nil })
+	assert.ErrorIs(t, err, errNotStreamable)
+}
+
+func TestParseContext(t *testing.T) {
+	cmds, err := ParseWithTimeout(strings.NewReader("FROM foo\nPARAMETER temperature 0.7\n"), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{{Name: "model", Args: "foo"}, {Name: "temperature", Args: "0.7"}}, cmds)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = ParseContext(ctx, strings.NewReader("INHERIT base\nPARAMETER temperature 0.7\n"), ParseOptions{
+		InheritResolver: func(string) (io.Reader, error) {
+			<-release
+			return strings.NewReader("FROM foo\n"), nil
+		},
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// a reader blocked mid-line is closed on cancellation
+	pr, pw := io.Pipe()
+	go pw.Write([]byte("FROM foo\nPARAMETER temp"))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = ParseContext(ctx, pr, ParseOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = pw.Write([]byte("erature 0.7\n"))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+func TestParseFileSortCanonical(t *testing.T) {
+	input := `PARAMETER temperature 0.7
+MESSAGE user Hi
+LICENSE MIT
+MESSAGE assistant Hello!
+SYSTEM You are helpful.
+ADAPTER ./lora.gguf
+FROM llama3
+TEMPLATE {{ .Prompt }}
+PARAMETER stop <|a|>
+MERGE llama3:0.5 mistral:0.5
+PARAMETER stop <|b|>
+VARIANT small
+PARAMETER num_ctx 2048
+FROM llama3:1b
+`
+
+	modelfile, err := ParseFileWithOptions(strings.NewReader(input), ParseOptions{SortCanonical: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "stop", Args: "<|a|>"},
+		{Name: "stop", Args: "<|b|>"},
+		{Name: "template", Args: "{{ .Prompt }}"},
+		{Name: "system", Args: "You are helpful."},
+		{Name: "adapter", Args: "./lora.gguf"},
+		{Name: "license", Args: "MIT"},
+		{Name: "message", Args: "user: Hi"},
+		{Name: "message", Args: "assistant: Hello!"},
+		{Name: "merge", Args: "llama3:0.5 mistral:0.5"},
+		{Name: "variant", Args: "small"},
+		{Name: "num_ctx", Args: "2048"},
+		{Name: "model", Args: "llama3:1b"},
+	}, modelfile.Commands)
+
+	// source order is kept by default
+	modelfile, err = ParseFile(strings.NewReader("PARAMETER temperature 0.7\nFROM llama3\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{{Name: "temperature", Args: "0.7"}, {Name: "model", Args: "llama3"}}, modelfile.Commands)
+}
+
+func TestParseFileSystem(t *testing.T) {
+	var cases = []struct {
+		value    string
+		expected string
+	}{
+		{`You are "helpful".`, `You are "helpful".`},
+		{`"You are a file parser."`, "You are a file parser."},
+		{"\"\"\"\nYou say \"hi\" and 'bye'.\nEscape \\\"\"\" too.\n\"\"\"", "\nYou say \"hi\" and 'bye'.\nEscape \"\"\" too.\n"},
+		{"\"\"\"She said \"\"no\"\".\"\"\"", `She said ""no"".`},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			// SYSTEM values are parsed exactly as TEMPLATE values are
+			modelfile, err := ParseFile(strings.NewReader("FROM foo\nSYSTEM " + c.value + "\nTEMPLATE " + c.value))
+			assert.NoError(t, err)
+			assert.Equal(t, []Command{
+				{Name: "model", Args: "foo"},
+				{Name: "system", Args: c.expected},
+				{Name: "template", Args: c.expected},
+			}, modelfile.Commands)
+		})
+	}
+}
+
+func TestParseFileTripleQuotesOnOwnLines(t *testing.T) {
+	var cases = []struct {
+		input string
+		err   error
+	}{
+		{"FROM foo\nTEMPLATE \"\"\"\n{{ .Prompt }}\n\"\"\"", nil},
+		{"FROM foo\r\nSYSTEM \"\"\"  \r\nYou are\r\na file parser.\r\n  \"\"\"\r\n", nil},
+		{"FROM foo\nMESSAGE user \"\"\"\nHey there!\n\"\"\" [os=linux]", nil},
+		{"FROM foo\nSYSTEM \"single line, regular quotes\"", nil},
+		{"FROM foo\nTEMPLATE \"\"\"{{ .Prompt }}\n\"\"\"", errTripleQuoteStyle},
+		{"FROM foo\nTEMPLATE \"\"\"\n{{ .Prompt }}\"\"\"", errTripleQuoteStyle},
+		{"FROM foo\nSYSTEM \"\"\"You are a file parser.\"\"\"", errTripleQuoteStyle},
+		{"FROM foo\nMESSAGE assistant \"\"\"Hello\nthere\"\"\"", errTripleQuoteStyle},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseFileWithOptions(strings.NewReader(c.input), ParseOptions{RequireTripleQuotesOnOwnLines: true})
+			assert.ErrorIs(t, err, c.err)
+
+			// both styles are accepted by default
+			_, err = ParseFile(strings.NewReader(c.input))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseFileSizeLimits(t *testing.T) {
+	var cases = []struct {
+		input string
+		opts  ParseOptions
+		err   error
+	}{
+		{"FROM foo\nSYSTEM You are a file parser.", ParseOptions{MaxValueBytes: 32, MaxInputBytes: 64}, nil},
+		{"FROM foo\nSYSTEM You are a file parser. Always parse things.", ParseOptions{MaxValueBytes: 32}, errValueTooLarge},
+		{"FROM foo\nTEMPLATE \"\"\"" + strings.Repeat("a\n", 1<<10), ParseOptions{MaxValueBytes: 32}, errValueTooLarge},
+		{"FROM foo\nSYSTEM You are a file parser.", ParseOptions{MaxInputBytes: 16}, errInputTooLarge},
+		{"FROM foo\nSYSTEM You are a file parser.", ParseOptions{MaxInputBytes: 38}, nil},
+		{"FROM foo\nSYSTEM You are a file parser.", ParseOptions{MaxInputBytes: 37}, errInputTooLarge},
+		// comments are only bounded by MaxInputBytes
+		{"# " + strings.Repeat("a", 64) + "\nFROM foo", ParseOptions{MaxValueBytes: 32, RetainLines: true}, nil},
+		{"FROM foo\n# " + strings.Repeat("a", 64), ParseOptions{MaxValueBytes: 32, RetainLines: true}, nil},
+		{"# " + strings.Repeat("a", 64) + "\nFROM foo", ParseOptions{MaxInputBytes: 32, RetainLines: true}, errInputTooLarge},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseFileWithOptions(strings.NewReader(c.input), c.opts)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+
+	_, err := ParseFileWithOptions(strings.NewReader("FROM foo\nSYSTEM You are a file parser. Always parse things."), ParseOptions{MaxValueBytes: 32})
+	assert.EqualError(t, err, "line 2, column 1: value exceeds maximum size")
+}
+
+func TestParseFileMultilineLineEndings(t *testing.T) {
+	var cases = []string{
+		"FROM foo\r\nTEMPLATE \"\"\"{{ .System }}\r\n{{ .Prompt }}\r\n\"\"\"\r\nSYSTEM \"You are\r\na file parser.\"\r\n",
+		"FROM foo\rTEMPLATE \"\"\"{{ .System }}\r{{ .Prompt }}\r\"\"\"\rSYSTEM \"You are\ra file parser.\"\r",
+		"FROM foo\nTEMPLATE \"\"\"{{ .System }}\n{{ .Prompt }}\n\"\"\"\nSYSTEM \"You are\na file parser.\"\n",
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c))
+			assert.NoError(t, err)
+			assert.Equal(t, []Command{
+				{Name: "model", Args: "foo"},
+				{Name: "template", Args: "{{ .System }}\n{{ .Prompt }}\n"},
+				{Name: "system", Args: "You are\na file parser."},
+			}, modelfile.Commands)
+		})
+	}
+}
+
 func TestParseFileBadCommand(t *testing.T) {
 	input := `
 FROM foo
@@ -177,7 +598,48 @@ FROM foo
 MESSAGE badguy I'm a bad guy!
 `,
 			nil,
-			errInvalidMessageRole,
+			ErrInvalidRole,
+		},
+		{
+			`
+FROM foo
+MESSAGE System You are a file parser.
+MESSAGE USER Hey there!
+MESSAGE Assistant[weight=2] Hello!
+`,
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "message", Args: "system: You are a file parser."},
+				{Name: "message", Args: "user: Hey there!"},
+				{Name: "message", Args: "assistant: Hello!", Weight: 2},
+			},
+			nil,
+		},
+		{
+			`
+FROM foo
+MESSAGE Moderator Be nice.
+`,
+			nil,
+			ErrInvalidRole,
+		},
+		{
+			`
+FROM foo
+MESSAGE tool {"result": 42}
+MESSAGE tool """
+{
+  "name": "get_weather",
+  "content": "It's 21\u00b0C and \"sunny\" in Paris"
+}
+"""
+`,
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "message", Args: `tool: {"result": 42}`},
+				{Name: "message", Args: "tool: \n{\n  \"name\": \"get_weather\",\n  \"content\": \"It's 21\\u00b0C and \\\"sunny\\\" in Paris\"\n}\n"},
+			},
+			nil,
 		},
 		{
 			`
@@ -185,14 +647,14 @@ FROM foo
 MESSAGE system
 `,
 			nil,
-			io.ErrUnexpectedEOF,
+			ErrMissingValue,
 		},
 		{
 			`
 FROM foo
 MESSAGE system`,
 			nil,
-			io.ErrUnexpectedEOF,
+			ErrMissingValue,
 		},
 	}
 
@@ -269,7 +731,7 @@ FROM foo
 SYSTEM """This is a multiline system.""
 			`,
 			nil,
-			io.ErrUnexpectedEOF,
+			ErrUnterminatedMultiline,
 		},
 		{
 			`
@@ -277,7 +739,7 @@ FROM foo
 SYSTEM "
 			`,
 			nil,
-			io.ErrUnexpectedEOF,
+			ErrUnterminatedMultiline,
 		},
 		{
 			`
@@ -435,6 +897,12 @@ FROM foo
 				{Name: "model", Args: "foo"},
 			},
 		},
+		{
+			"#FROM bar\n  # indented comment\nFROM foo # inline comment\n##\n",
+			[]Command{
+				{Name: "model", Args: "foo"},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -500,8 +968,780 @@ MESSAGE assistant Hello, I want to parse all the things!
 			modelfile2, err := ParseFile(strings.NewReader(modelfile.String()))
 			assert.NoError(t, err)
 
-			assert.Equal(t, modelfile, modelfile2)
+			assert.Equal(t, modelfile.Commands, modelfile2.Commands)
+		})
+	}
+
+}
+
+func TestParseFileFormatBool(t *testing.T) {
+	var cases = map[string]string{
+		"true":  "true",
+		"True":  "true",
+		"t":     "true",
+		"1":     "true",
+		"yes":   "true",
+		"On":    "true",
+		"false": "false",
+		"FALSE": "false",
+		"0":     "false",
+		"no":    "false",
+		"off":   "false",
+	}
+
+	for k, v := range cases {
+		t.Run(k, func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader("FROM foo\nPARAMETER penalize_newline " + k))
+			assert.NoError(t, err)
+
+			assert.Equal(t, "FROM foo\nPARAMETER penalize_newline "+v+"\n", modelfile.String())
 		})
 	}
 
+	t.Run("non-bool", func(t *testing.T) {
+		modelfile, err := ParseFile(strings.NewReader("FROM foo\nPARAMETER num_ctx 1"))
+		assert.NoError(t, err)
+
+		assert.Equal(t, "FROM foo\nPARAMETER num_ctx 1\n", modelfile.String())
+	})
+}
+
+func TestParseFileExtraCommands(t *testing.T) {
+	opts := ParseOptions{
+		ExtraCommands: map[string]CommandSpec{
+			"capability": {Value: ValueSingleLine},
+			"readme":     {Value: ValueMultiline},
+			"example":    {Value: ValueMessage},
+			"option":     {Value: ValueParameter},
+		},
+	}
+
+	var cases = []struct {
+		input    string
+		expected []Command
+		err      error
+	}{
+		{
+			`
+FROM foo
+CAPABILITY tools
+README """
+# foo
+A model which parses files.
+"""
+EXAMPLE user Hey there!
+OPTION flash_attention true
+`,
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "capability", Args: "tools", spec: &CommandSpec{Value: ValueSingleLine}},
+				{Name: "readme", Args: "\n# foo\nA model which parses files.\n", spec: &CommandSpec{Value: ValueMultiline}},
+				{Name: "example", Args: "user: Hey there!", spec: &CommandSpec{Value: ValueMessage}},
+				{Name: "flash_attention", Args: "true"},
+			},
+			nil,
+		},
+		{
+			`
+FROM foo
+CAPABILITY """
+tools
+"""
+`,
+			nil,
+			errMultilineValue,
+		},
+		{
+			`
+FROM foo
+EXAMPLE moderator Hey there!
+`,
+			nil,
+			ErrInvalidRole,
+		},
+		{
+			`
+FROM foo
+UNKNOWN value
+`,
+			nil,
+			errInvalidCommand,
+		},
+		{
+			`
+FROM capability
+`,
+			nil,
+			errFromCommand,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), opts)
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				assert.Equal(t, c.expected, modelfile.Commands)
+
+				modelfile2, err := ParseFileWithOptions(strings.NewReader(modelfile.String()), opts)
+				assert.NoError(t, err)
+				assert.Equal(t, modelfile.Commands, modelfile2.Commands)
+			}
+		})
+	}
+
+	modelfile, err := ParseFileWithOptions(strings.NewReader("FROM foo\nCAPABILITY tools\nEXAMPLE user[weight=2] Hi\nOPTION seed 1"), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "FROM foo\nCAPABILITY tools\nEXAMPLE user[weight=2] Hi\nPARAMETER seed 1\n", modelfile.String())
+
+	// extra commands are not parameters
+	params, err := CollectParameters(modelfile.Commands)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"seed": int64(1)}, params)
+	assert.Equal(t, "CAPABILITY", Directives(modelfile.Commands)[1].Kind)
+	assert.Empty(t, Lint(modelfile.Commands, ValidateOptions{}))
+
+	_, err = ParseFile(strings.NewReader("FROM foo\nCAPABILITY tools"))
+	assert.ErrorIs(t, err, errInvalidCommand)
+}
+
+func TestParseFileConstraints(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []Command
+		err      error
+	}{
+		{
+			"FROM foo\nPARAMETER num_gpu 0 [os=linux]",
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "num_gpu", Args: "0", Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER num_thread 8 [arch=arm64]\nPARAMETER num_thread 4",
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "num_thread", Args: "8", Constraints: []Constraint{{Key: "arch", Op: "=", Value: "arm64"}}},
+				{Name: "num_thread", Args: "4"},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nSYSTEM \"\"\"\nYou are a file parser.\n\"\"\" [os=darwin, arch=arm64]",
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "system", Args: "\nYou are a file parser.\n", Constraints: []Constraint{
+					{Key: "os", Op: "=", Value: "darwin"},
+					{Key: "arch", Op: "=", Value: "arm64"},
+				}},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER stop [INST]\nPARAMETER stop [/INST]",
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "stop", Args: "[INST]"},
+				{Name: "stop", Args: "[/INST]"},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER num_gpu 0 [gpu=nvidia]",
+			nil,
+			errInvalidConstraint,
+		},
+		{
+			"FROM foo\nPARAMETER num_gpu 0 [os=]",
+			nil,
+			errInvalidConstraint,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c.input))
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				assert.Equal(t, c.expected, modelfile.Commands)
+
+				modelfile2, err := ParseFile(strings.NewReader(modelfile.String()))
+				assert.NoError(t, err)
+				assert.Equal(t, modelfile, modelfile2)
+			}
+		})
+	}
+}
+
+func TestParseFileRequiresFrom(t *testing.T) {
+	opts := ParseOptions{
+		AllowNoFrom: true,
+		ExtraCommands: map[string]CommandSpec{
+			"capability": {Value: ValueSingleLine},
+			"quantize":   {Value: ValueSingleLine, RequiresFrom: true},
+		},
+	}
+
+	var cases = []struct {
+		input    string
+		expected []Command
+		err      error
+	}{
+		{
+			"CAPABILITY tools\nPARAMETER temperature 0.7",
+			[]Command{{Name: "capability", Args: "tools", spec: &CommandSpec{Value: ValueSingleLine}}, {Name: "temperature", Args: "0.7"}},
+			nil,
+		},
+		{
+			"CAPABILITY tools\nQUANTIZE q4_0",
+			nil,
+			ErrMissingFrom,
+		},
+		{
+			"QUANTIZE q4_0\nFROM foo",
+			[]Command{{Name: "quantize", Args: "q4_0", spec: &CommandSpec{Value: ValueSingleLine}}, {Name: "model", Args: "foo"}},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), opts)
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				assert.Equal(t, c.expected, modelfile.Commands)
+			}
+		})
+	}
+
+	_, err := ParseFileWithOptions(strings.NewReader("QUANTIZE q4_0"), opts)
+	assert.EqualError(t, err, "no FROM line: QUANTIZE requires FROM")
+}
+
+func TestParseFileQuotedValues(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []Command
+		err      error
+	}{
+		{
+			`PARAMETER stop "a b"`,
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: "a b"}},
+			nil,
+		},
+		{
+			`PARAMETER stop 'a b'`,
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: "a b"}},
+			nil,
+		},
+		{
+			`PARAMETER stop '"a" # b'`,
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: `"a" # b`}},
+			nil,
+		},
+		{
+			`PARAMETER stop a'b`,
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: "a'b"}},
+			nil,
+		},
+		{
+			"PARAMETER stop 'a b\nPARAMETER temperature 0.7",
+			nil,
+			ErrUnterminatedQuote,
+		},
+		{
+			`PARAMETER stop 'a b"`,
+			nil,
+			ErrUnterminatedQuote,
+		},
+		{
+			`PARAMETER stop "a b`,
+			nil,
+			ErrUnterminatedQuote,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader("FROM foo\n" + c.input))
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				assert.Equal(t, c.expected, modelfile.Commands)
+			}
+		})
+	}
+}
+
+func TestParseFileLineContinuation(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []Command
+	}{
+		{
+			"SYSTEM You are a helpful \\\nassistant.",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "You are a helpful assistant."}},
+		},
+		{
+			"SYSTEM You are a helpful \\\r\nassistant.\r\n",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "You are a helpful assistant."}},
+		},
+		{
+			"PARAMETER stop a\\\nb\\\nc\nPARAMETER temperature 0.7",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: "abc"}, {Name: "temperature", Args: "0.7"}},
+		},
+		{
+			"SYSTEM C:\\\\\nPARAMETER temperature 0.7",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: `C:\`}, {Name: "temperature", Args: "0.7"}},
+		},
+		{
+			"SYSTEM C:\\\\",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: `C:\`}},
+		},
+		{
+			"SYSTEM \"\"\"a \\\nb\"\"\"",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "a \\\nb"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader("FROM foo\n" + c.input))
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, modelfile.Commands)
+		})
+	}
+}
+
+func TestParseFileEscapedTripleQuotes(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected string
+	}{
+		{"TEMPLATE \"\"\"Use \\\"\"\" to quote\nmultiline values.\"\"\"", "Use \"\"\" to quote\nmultiline values."},
+		{"TEMPLATE \"\"\"\\\"\"\"\"\"\"", "\"\"\""},
+		{"TEMPLATE \"\"\"C:\\models\\\nllama3\"\"\"", "C:\\models\\\nllama3"},
+		{"TEMPLATE \"\"\"a \\\" b\"\"\"", "a \\\" b"},
+		// a backslash before the closing """ is kept
+		{"TEMPLATE \"\"\"C:\\models\\\"\"\"", "C:\\models\\"},
+		{"TEMPLATE \"\"\"say \"hi\"\nC:\\\"\"\"", "say \"hi\"\nC:\\"},
+		{"TEMPLATE \"\"\"\\\"\"\"", "\\"},
+		{"TEMPLATE \"\"\"a\\\\\"\"\"\"\"\"", "a\\\"\"\""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader("FROM foo\n" + c.input))
+			assert.NoError(t, err)
+			assert.Equal(t, []Command{{Name: "model", Args: "foo"}, {Name: "template", Args: c.expected}}, modelfile.Commands)
+
+			// the value is escaped when written back out
+			modelfile2, err := ParseFile(strings.NewReader(modelfile.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, modelfile.Commands, modelfile2.Commands)
+		})
+	}
+}
+
+func TestParseFilePosition(t *testing.T) {
+	var cases = []struct {
+		input     string
+		line, col int
+		err       error
+	}{
+		{"FROM foo\nPARAMETER param1\n", 2, 17, ErrMissingValue},
+		{"FROM foo\r\nPARAMETER param1\r\n", 2, 17, ErrMissingValue},
+		{"FROM foo\n\n  BOGUS value\n", 3, 3, errInvalidCommand},
+		{"FROM foo\r\n\r\n# comment\r\nMESSAGE badguy hi\r\n", 4, 1, ErrInvalidRole},
+		{"FROM foo\nSYSTEM \"\"\"\nunterminated\n", 2, 1, ErrUnterminatedMultiline},
+		{"FROM foo\nPARAMETER stop", 2, 15, ErrMissingValue},
+		{"FROM foo\nPARAM=ETER stop", 2, 6, errInvalidCommand},
+		{"FRO#M foo\n", 1, 4, errUnexpectedHash},
+		{"FROM foo\n  SYSTEM# hi\n", 2, 9, errUnexpectedHash},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseFile(strings.NewReader(c.input))
+			assert.ErrorIs(t, err, c.err)
+
+			var perr *ParseError
+			if assert.ErrorAs(t, err, &perr) {
+				assert.Equal(t, c.line, perr.Line)
+				assert.Equal(t, c.col, perr.Col)
+				assert.Equal(t, fmt.Sprintf("line %d, column %d: %s", c.line, c.col, perr.Msg), err.Error())
+			}
+		})
+	}
+}
+
+func TestParseErrorRender(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected string
+	}{
+		{
+			"FROM foo\nPARAMETER param1\nPARAMETER param2 value2\n",
+			"line 2, column 17: missing value for param1: unexpected EOF\nPARAMETER param1\n                ^",
+		},
+		{
+			"FROM foo\r\n\tBOGUS value\r\nPARAMETER param2 value2\r\n",
+			"line 2, column 2: " + errInvalidCommand.Error() + "\n\tBOGUS value\n\t^",
+		},
+		{
+			"FROM foo\nMESSAGE badguy hi",
+			"line 2, column 1: " + ErrInvalidRole.Error() + "\nMESSAGE badguy hi\n^",
+		},
+		{
+			"FROM foo\nPARAMETER stop",
+			"line 2, column 15: missing value: unexpected EOF\nPARAMETER stop\n              ^",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseFileWithOptions(strings.NewReader(c.input), ParseOptions{RetainLines: true})
+
+			var perr *ParseError
+			if assert.ErrorAs(t, err, &perr) {
+				assert.Equal(t, c.expected, perr.Render())
+			}
+		})
+	}
+
+	_, err := ParseFile(strings.NewReader("FROM foo\nMESSAGE badguy hi"))
+
+	var perr *ParseError
+	if assert.ErrorAs(t, err, &perr) {
+		assert.Equal(t, err.Error(), perr.Render())
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	var cases = []struct {
+		name    string
+		content string
+	}{
+		{"Modelfile", "FROM foo\nPARAMETER temperature 0.7\n"},
+		{"Modelfile.bom", "\uFEFFFROM foo\r\nPARAMETER temperature 0.7\r\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(dir, c.name)
+			if err := os.WriteFile(path, []byte(c.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmds, err := ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, []Command{{Name: "model", Args: "foo"}, {Name: "temperature", Args: "0.7"}}, cmds)
+		})
+	}
+
+	path := filepath.Join(dir, "Modelfile.bad")
+	if err := os.WriteFile(path, []byte("\uFEFFFROM foo\nMESSAGE badguy hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadFile(path)
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	assert.ErrorContains(t, err, path+": line 2, column 1: ")
+
+	_, err = ReadFile(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseWithComments(t *testing.T) {
+	input := `# Modelfile for a file parser
+FROM foo # the base model
+
+# sampling
+PARAMETER temperature 0.7 # a little warm
+PARAMETER stop ### User:
+SYSTEM """You are a # file parser."""
+# @deprecated use bar
+# trailing comment`
+
+	cmds, err := ParseWithComments(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "comment", Args: "Modelfile for a file parser"},
+		{Name: "model", Args: "foo"},
+		{Name: "comment", Args: "the base model"},
+		{Name: "comment", Args: "sampling"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "comment", Args: "a little warm"},
+		{Name: "stop", Args: "### User:"},
+		{Name: "system", Args: "You are a # file parser."},
+		{Name: "annotation", Args: "deprecated use bar"},
+		{Name: "comment", Args: "trailing comment"},
+	}, cmds)
+
+	// comments are written back out
+	again, err := ParseWithComments(strings.NewReader(File{Commands: cmds}.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, cmds, again)
+
+	// ParseFile drops comments
+	modelfile, err := ParseFile(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "foo"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "stop", Args: "### User:"},
+		{Name: "system", Args: "You are a # file parser."},
+		{Name: "annotation", Args: "deprecated use bar"},
+	}, modelfile.Commands)
+}
+
+func TestParseFileImplicitFrom(t *testing.T) {
+	var cases = []struct {
+		input    string
+		opts     ParseOptions
+		expected []Command
+		err      error
+	}{
+		{
+			"llama3\nPARAMETER temperature 0.7",
+			ParseOptions{ImplicitFrom: true},
+			[]Command{{Name: "model", Args: "llama3"}, {Name: "temperature", Args: "0.7"}},
+			nil,
+		},
+		{
+			"# a terse Modelfile\n\nllama3:8b-instruct\n",
+			ParseOptions{ImplicitFrom: true},
+			[]Command{{Name: "model", Args: "llama3:8b-instruct"}},
+			nil,
+		},
+		{
+			"FROM llama3\nPARAMETER temperature 0.7",
+			ParseOptions{ImplicitFrom: true},
+			[]Command{{Name: "model", Args: "llama3"}, {Name: "temperature", Args: "0.7"}},
+			nil,
+		},
+		{
+			"PARAMETER temperature 0.7\nFROM llama3",
+			ParseOptions{ImplicitFrom: true},
+			[]Command{{Name: "temperature", Args: "0.7"}, {Name: "model", Args: "llama3"}},
+			nil,
+		},
+		{
+			"system\nFROM llama3",
+			ParseOptions{ImplicitFrom: true},
+			nil,
+			errInvalidCommand,
+		},
+		{
+			"llama3\nPARAMETER temperature 0.7",
+			ParseOptions{},
+			nil,
+			errInvalidCommand,
+		},
+		{
+			"# nothing here\n",
+			ParseOptions{ImplicitFrom: true},
+			nil,
+			ErrMissingFrom,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), c.opts)
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				assert.Equal(t, c.expected, modelfile.Commands)
+			}
+		})
+	}
+}
+
+func TestParseFileCollapseBlankLines(t *testing.T) {
+	input := "FROM foo\nTEMPLATE \"\"\"{{ .System }}\n\n\n\n{{ .Prompt }}\n\n{{ .Response }}\"\"\"\n\n\n\nSYSTEM \"a\n\n\nb\"\n"
+
+	var cases = []struct {
+		opts     ParseOptions
+		expected []Command
+	}{
+		{
+			ParseOptions{},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "template", Args: "{{ .System }}\n\n\n\n{{ .Prompt }}\n\n{{ .Response }}"},
+				{Name: "system", Args: "a\n\n\nb"},
+			},
+		},
+		{
+			ParseOptions{CollapseBlankLines: true},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "template", Args: "{{ .System }}\n\n{{ .Prompt }}\n\n{{ .Response }}"},
+				{Name: "system", Args: "a\n\nb"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(input), c.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, modelfile.Commands)
+		})
+	}
+}
+
+// randomCommands generates a random, valid list of commands covering every
+// command kind which survives parsing.
+// representable reports whether quote can represent s, see quote.
+func representable(s string) bool {
+	lines := strings.Split(s, "\n")
+	for _, line := range lines[:len(lines)-1] {
+		if value, _, _ := cutConstraints(line); strings.HasSuffix(value, `"""`) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var lineEndings = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func randomCommands(r *rand.Rand) []Command {
+	words := []string{"a", "b c", "'", `"`, `""`, `"quoted"`, " ", "\t", "\n", "\r", "[INST]", "[os=linux]", "{{ .Prompt }}", "é", "#", "\\"}
+
+	values := func(replacer *strings.Replacer) string {
+		for {
+			var sb strings.Builder
+			for range r.Intn(8) {
+				sb.WriteString(words[r.Intn(len(words))])
+			}
+
+			// """ followed by a newline or a constraint list cannot be
+			// represented, see quote
+			if s := replacer.Replace(sb.String()); representable(lineEndings.Replace(s)) {
+				return s
+			}
+		}
+	}
+
+	value := func() string {
+		return values(strings.NewReplacer())
+	}
+
+	line := func() string {
+		return values(strings.NewReplacer("\n", "", "\r", "", "\t", ""))
+	}
+
+	token := func() string {
+		return strings.Fields("x" + strings.NewReplacer(`"`, "", "#", "", "[", "", "]", "", `\`, "").Replace(line()))[0]
+	}
+
+	cmds := []Command{{Name: "model", Args: "llama3:" + token()}}
+	for range r.Intn(10) {
+		var cmd Command
+		switch r.Intn(9) {
+		case 0:
+			// only conditional FROM commands may repeat
+			cmd = Command{Name: "model", Args: token(), Constraints: []Constraint{{Key: "os", Op: "=", Value: "linux"}}}
+		case 1:
+			cmd = Command{Name: "adapter", Args: token()}
+		case 2:
+			cmd = Command{Name: "license", Args: value()}
+		case 3:
+			cmd = Command{Name: "template", Args: value()}
+		case 4:
+			cmd = Command{Name: "system", Args: value()}
+		case 5:
+			roles := []string{"system", "user", "assistant", "tool"}
+			cmd = Command{Name: "message", Args: roles[r.Intn(len(roles))] + ": " + value()}
+		case 6:
+			cmd = Command{Name: "variant", Args: line()}
+		case 7:
+			params := []string{"stop", "temperature", "num_ctx", "penalize_newline"}
+			values := []string{line(), "0.7", "4096", "true"}
+			i := r.Intn(len(params))
+			cmd = Command{Name: params[i], Args: values[i]}
+		case 8:
+			cmd = Command{Name: "annotation", Args: "key " + token()}
+		}
+
+		if cmd.Name != "annotation" && cmd.Name != "model" && r.Intn(4) == 0 {
+			cmd.Constraints = []Constraint{{Key: "os", Op: "=", Value: "linux"}}
+		}
+
+		cmds = append(cmds, cmd)
+	}
+
+	return cmds
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for i := range 64 {
+		f.Add(int64(i))
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		cmds := randomCommands(rand.New(rand.NewSource(seed)))
+
+		s := File{Commands: cmds}.String()
+		modelfile, err := ParseFile(strings.NewReader(s))
+		if !assert.NoError(t, err, s) {
+			return
+		}
+
+		// line endings in values are always parsed as \n
+		for i := range cmds {
+			cmds[i].Args = lineEndings.Replace(cmds[i].Args)
+		}
+
+		assert.Equal(t, cmds, modelfile.Commands, s)
+	})
+}
+
+func TestParseFileRejectRawTabs(t *testing.T) {
+	var cases = []struct {
+		input    string
+		opts     ParseOptions
+		expected []Command
+		err      error
+	}{
+		{
+			"FROM foo\nPARAMETER stop a\tb",
+			ParseOptions{},
+			[]Command{{Name: "model", Args: "foo"}, {Name: "stop", Args: "a\tb"}},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER stop a\tb",
+			ParseOptions{RejectRawTabsInValues: true},
+			nil,
+			errRawTab,
+		},
+		{
+			"FROM foo\nSYSTEM \"a\tb\"",
+			ParseOptions{RejectRawTabsInValues: true},
+			[]Command{{Name: "model", Args: "foo"}, {Name: "system", Args: "a\tb"}},
+			nil,
+		},
+		{
+			"FROM foo\nTEMPLATE \"\"\"a\n\tb\"\"\"",
+			ParseOptions{RejectRawTabsInValues: true},
+			[]Command{{Name: "model", Args: "foo"}, {Name: "template", Args: "a\n\tb"}},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), c.opts)
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				assert.Equal(t, c.expected, modelfile.Commands)
+			}
+		})
+	}
 }
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Reformat rewrites the Modelfile src in canonical form, like gofmt: commands
+// are upper case and separated from their values by a single space, multiline
+// values use triple quotes, runs of blank lines are collapsed and leading
+// indentation is removed. Comments, blank line separated groups and the order
+// of commands are preserved. INCLUDE commands are kept rather than expanded.
+func Reformat(src []byte) ([]byte, error) {
+	var out bytes.Buffer
+	if err := Normalize(bytes.NewReader(src), &out); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// Normalize reads a Modelfile from r and writes its canonical form, as
+// produced by Reformat, to w. Nothing is written if r fails to parse.
+func Normalize(r io.Reader, w io.Writer) error {
+	f, err := ParseFileWithOptions(r, ParseOptions{AllowNoFrom: true, trivia: true})
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	var line strings.Builder
+	var written, blank bool
+	flush := func() {
+		if line.Len() > 0 {
+			bw.WriteString(line.String())
+			bw.WriteByte('\n')
+			line.Reset()
+			written = true
+		}
+	}
+
+	for _, cmd := range f.Commands {
+		switch cmd.Name {
+		case "":
+			blank = written || line.Len() > 0
+			continue
+		case "#inline":
+			// an inline comment stays on the line of the command before it
+			line.WriteString(" # " + cmd.Args)
+			continue
+		}
+
+		flush()
+		if blank {
+			bw.WriteByte('\n')
+			blank = false
+		}
+
+		switch cmd.Name {
+		case "#":
+			line.WriteString("#" + strings.TrimRight(cmd.Args, " \t"))
+		default:
+			line.WriteString(cmd.format(quoteBlock))
+		}
+	}
+
+	flush()
+	return bw.Flush()
+}
+
+// quoteBlock is like quote but always uses triple quotes for multiline
+// values.
+func quoteBlock(s string) string {
+	if strings.Contains(s, "\n") {
+		return `"""` + strings.ReplaceAll(s, `"""`, `\"""`) + `"""`
+	}
+
+	return quote(s)
+}
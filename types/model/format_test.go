@@ -0,0 +1,99 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReformat(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected string
+	}{
+		{
+			`
+
+# a messy Modelfile
+   from   llama3
+parameter temperature   0.7
+PARAMETER penalize_newline yes
+
+
+
+  system "You are
+a helpful assistant."
+#no space after hash   
+template """{{ .System }}
+{{ .Prompt }}"""
+
+# @deprecated use llama3.1
+message user hi
+INCLUDE common.modelfile
+
+`,
+			`# a messy Modelfile
+FROM llama3
+PARAMETER temperature 0.7
+PARAMETER penalize_newline true
+
+SYSTEM """You are
+a helpful assistant."""
+#no space after hash
+TEMPLATE """{{ .System }}
+{{ .Prompt }}"""
+
+# @deprecated use llama3.1
+MESSAGE user hi
+INCLUDE common.modelfile
+`,
+		},
+		{
+			"FROM llama3   # the base model\nPARAMETER num_ctx 4096 [os=linux]\t#more memory\n",
+			"FROM llama3 # the base model\nPARAMETER num_ctx 4096 [os=linux] # more memory\n",
+		},
+		{
+			"FROM llama3\nSYSTEM \"a b\"   # brief\nTEMPLATE \"\"\"\n{{ .Prompt }}\n\"\"\"\t# raw\n",
+			"FROM llama3\nSYSTEM a b # brief\nTEMPLATE \"\"\"\n{{ .Prompt }}\n\"\"\" # raw\n",
+		},
+		{
+			"FROM llama3\r\nPARAMETER num_ctx 4096 [os=linux]\r\n\r\nTOKENIZER @missing.json",
+			"FROM llama3\nPARAMETER num_ctx 4096 [os=linux]\n\nTOKENIZER @missing.json\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			out, err := Reformat([]byte(c.input))
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, string(out))
+
+			// reformatting is idempotent
+			again, err := Reformat(out)
+			assert.NoError(t, err)
+			assert.Equal(t, string(out), string(again))
+		})
+	}
+
+	_, err := Reformat([]byte("FROM llama3\nBOGUS value"))
+	assert.ErrorIs(t, err, errInvalidCommand)
+}
+
+func TestNormalize(t *testing.T) {
+	input := "from llama3   # base\n\n\nparameter temperature   0.7\nsystem \"You are\na file parser.\"\n"
+
+	var out bytes.Buffer
+	assert.NoError(t, Normalize(strings.NewReader(input), &out))
+	assert.Equal(t, "FROM llama3 # base\n\nPARAMETER temperature 0.7\nSYSTEM \"\"\"You are\na file parser.\"\"\"\n", out.String())
+
+	// normalizing normalized output is a no-op
+	var again bytes.Buffer
+	assert.NoError(t, Normalize(bytes.NewReader(out.Bytes()), &again))
+	assert.Equal(t, out.String(), again.String())
+
+	var failed bytes.Buffer
+	assert.ErrorIs(t, Normalize(strings.NewReader("FROM llama3\nBOGUS value"), &failed), errInvalidCommand)
+	assert.Zero(t, failed.Len())
+}
@@ -0,0 +1,41 @@
+package model
+
+// ggufKeys maps PARAMETER names to the GGUF metadata keys which hold the
+// recommended sampling settings of a model.
+var ggufKeys = map[string]string{
+	"temperature":    "general.sampling.temp",
+	"top_k":          "general.sampling.top_k",
+	"top_p":          "general.sampling.top_p",
+	"repeat_last_n":  "general.sampling.penalty_last_n",
+	"repeat_penalty": "general.sampling.penalty_repeat",
+	"mirostat":       "general.sampling.mirostat",
+	"mirostat_tau":   "general.sampling.mirostat_tau",
+	"mirostat_eta":   "general.sampling.mirostat_eta",
+}
+
+// GGUFMetadata returns the parameters in cmds which have a GGUF metadata key,
+// keyed by that key. Values have the types GGUF stores them as: int32 for
+// integer parameters and float32 for float parameters. The last value of a
+// parameter wins and values which fail to parse are skipped.
+func GGUFMetadata(cmds []Command) map[string]any {
+	kv := make(map[string]any)
+	for _, cmd := range cmds {
+		key, ok := ggufKeys[cmd.Name]
+		if !ok {
+			continue
+		}
+
+		v, err := ParseParameter(cmd.Name, cmd.Args)
+		if err != nil {
+			continue
+		}
+
+		if i, ok := v.(int64); ok {
+			v = int32(i)
+		}
+
+		kv[key] = v
+	}
+
+	return kv
+}
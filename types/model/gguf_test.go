@@ -0,0 +1,25 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGGUFMetadata(t *testing.T) {
+	modelfile, err := ParseFile(strings.NewReader(`FROM foo
+PARAMETER temperature 0.7
+PARAMETER top_k 20
+PARAMETER top_k 40
+PARAMETER repeat_penalty 1.1
+PARAMETER num_ctx 4096
+PARAMETER stop <|eot_id|>
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"general.sampling.temp":           float32(0.7),
+		"general.sampling.top_k":          int32(40),
+		"general.sampling.penalty_repeat": float32(1.1),
+	}, GGUFMetadata(modelfile.Commands))
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/ollama/ollama/format"
+)
+
+// DefaultKVBytesPerToken is the KV cache size of one token of context
+// assumed when Hardware.KVBytesPerToken is not set. It is that of an 8B
+// parameter model with grouped-query attention and an f16 cache.
+const DefaultKVBytesPerToken = 128 << 10
+
+// Hardware describes the machine a model is expected to run on.
+type Hardware struct {
+	// VRAM is the GPU memory available, in bytes. Zero means there is no GPU.
+	VRAM uint64
+
+	// KVBytesPerToken is the KV cache size of one token of context for the
+	// base model. Zero means DefaultKVBytesPerToken.
+	KVBytesPerToken uint64
+}
+
+// lintHardware reports num_ctx and num_gpu settings which clearly exceed the
+// hardware returned by resolve: a context whose KV cache alone does not fit
+// in VRAM, or layers offloaded to a GPU which is not there. Only the last
+// value of each parameter outside of any VARIANT is checked.
+func lintHardware(cmds []Command, resolve func() (Hardware, error)) []Diagnostic {
+	var numCtx, numGPU int64
+	for _, cmd := range cmds {
+		if cmd.Name == "variant" {
+			break
+		}
+
+		if len(cmd.Constraints) > 0 || cmd.Name != "num_ctx" && cmd.Name != "num_gpu" {
+			continue
+		}
+
+		v, err := ParseParameter(cmd.Name, cmd.Args)
+		if err != nil {
+			continue
+		}
+
+		if cmd.Name == "num_ctx" {
+			numCtx = v.(int64)
+		} else {
+			numGPU = v.(int64)
+		}
+	}
+
+	if numCtx <= 0 && numGPU <= 0 {
+		return nil
+	}
+
+	hw, err := resolve()
+	if err != nil {
+		return []Diagnostic{{
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("could not determine the available hardware: %v", err),
+		}}
+	}
+
+	perToken := hw.KVBytesPerToken
+	if perToken == 0 {
+		perToken = DefaultKVBytesPerToken
+	}
+
+	var diags []Diagnostic
+	if numGPU > 0 && hw.VRAM == 0 {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("num_gpu %d offloads layers to a GPU but no GPU memory is available", numGPU),
+		})
+	}
+
+	if kv := uint64(numCtx) * perToken; numCtx > 0 && hw.VRAM > 0 && kv > hw.VRAM {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("num_ctx %d needs about %s of KV cache but only %s of VRAM is available", numCtx, format.HumanBytes2(kv), format.HumanBytes2(hw.VRAM)),
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	errIncludeCycle      = errors.New("include cycle")
+	errIncludeNotAllowed = errors.New("INCLUDE is not allowed here")
+)
+
+// parseInclude parses the Modelfile fragment at path, resolved against
+// opts.BaseDir, and returns its commands in place of the INCLUDE command.
+func parseInclude(path string, opts ParseOptions) ([]Command, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(opts.BaseDir, path)
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(opts.includes, path) {
+		return nil, fmt.Errorf("%w: %s", errIncludeCycle, path)
+	}
+
+	if opts.ctx != nil && opts.ctx.Err() != nil {
+		return nil, opts.ctx.Err()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	opts.BaseDir = filepath.Dir(path)
+	opts.AllowNoFrom = true
+	opts.includes = append(slices.Clip(opts.includes), path)
+
+	modelfile, err := ParseFileWithOptions(f, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i := range modelfile.Commands {
+		modelfile.Commands[i] = resolveIncludedPaths(modelfile.Commands[i], opts.BaseDir)
+	}
+
+	return modelfile.Commands, nil
+}
+
+// resolveIncludedPaths rewrites the relative FROM, ADAPTER and TOKENIZER @file
+// paths of cmd, parsed from a file included from dir, to absolute paths so
+// that they refer to the same files once cmd is inlined into the including
+// file. Registry references, blobs and paths beginning with ~ are unchanged.
+func resolveIncludedPaths(cmd Command, dir string) Command {
+	isRelative := func(path string) bool {
+		return !filepath.IsAbs(path) && path != "~" && !strings.HasPrefix(path, "~/")
+	}
+
+	switch cmd.Name {
+	case "model":
+		if isPathLike(cmd.Args) && isRelative(cmd.Args) {
+			cmd.Args = filepath.Join(dir, cmd.Args)
+		}
+	case "adapter":
+		cmd.Args = resolveAdapterPath(cmd.Args, dir)
+	case "tokenizer":
+		ref, ok := strings.CutPrefix(cmd.Args, "@")
+		if _, local := localPath(cmd.Args, dir); ok && local && isRelative(ref) {
+			cmd.Args = "@" + filepath.Join(dir, ref)
+		}
+	}
+
+	return cmd
+}
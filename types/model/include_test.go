@@ -0,0 +1,126 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileInclude(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "presets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"presets/creative": "PARAMETER temperature 1.2\nINCLUDE stops\n",
+		"presets/stops":    "PARAMETER stop <|im_end|>\n",
+		"loop":             "INCLUDE loop\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	input := `
+FROM foo
+INCLUDE presets/creative
+SYSTEM You are a file parser.
+`
+
+	t.Run("base dir", func(t *testing.T) {
+		modelfile, err := ParseFileWithOptions(strings.NewReader(input), ParseOptions{BaseDir: dir, AllowInclude: true})
+		assert.NoError(t, err)
+		assert.Equal(t, []Command{
+			{Name: "model", Args: "foo"},
+			{Name: "temperature", Args: "1.2"},
+			{Name: "stop", Args: "<|im_end|>"},
+			{Name: "system", Args: "You are a file parser."},
+		}, modelfile.Commands)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := ParseFileWithOptions(strings.NewReader(input), ParseOptions{BaseDir: t.TempDir(), AllowInclude: true})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		modelfile, err := ParseFileWithOptions(strings.NewReader("FROM foo\nINCLUDE "+filepath.Join(dir, "presets", "stops")), ParseOptions{AllowInclude: true})
+		assert.NoError(t, err)
+		assert.Equal(t, []Command{
+			{Name: "model", Args: "foo"},
+			{Name: "stop", Args: "<|im_end|>"},
+		}, modelfile.Commands)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		// INCLUDE must be enabled explicitly so that untrusted Modelfiles
+		// cannot read files from disk
+		_, err := ParseFile(strings.NewReader("FROM foo\nINCLUDE " + filepath.Join(dir, "presets", "stops")))
+		assert.ErrorIs(t, err, errIncludeNotAllowed)
+
+		_, err = ParseFileWithOptions(strings.NewReader(input), ParseOptions{BaseDir: dir})
+		assert.ErrorIs(t, err, errIncludeNotAllowed)
+	})
+
+	t.Run("read file", func(t *testing.T) {
+		// ReadFile resolves includes against the Modelfile's directory
+		path := filepath.Join(dir, "Modelfile")
+		if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmds, err := ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, []Command{
+			{Name: "model", Args: "foo"},
+			{Name: "temperature", Args: "1.2"},
+			{Name: "stop", Args: "<|im_end|>"},
+			{Name: "system", Args: "You are a file parser."},
+		}, cmds)
+	})
+
+	t.Run("relative paths", func(t *testing.T) {
+		// paths in an included file are relative to that file, and are
+		// returned as absolute paths so that they resolve the same way
+		// against the including file
+		sub := filepath.Join(dir, "sub")
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		fragment := "FROM ./model.gguf\nADAPTER name=style ./lora.gguf 0.5\nTOKENIZER @tokenizer.json\n"
+		for name, content := range map[string]string{"frag": fragment, "tokenizer.json": "{}"} {
+			if err := os.WriteFile(filepath.Join(sub, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		modelfile, err := ParseFileWithOptions(strings.NewReader("INCLUDE sub/frag\n"), ParseOptions{BaseDir: dir, AllowInclude: true})
+		assert.NoError(t, err)
+		assert.Equal(t, []Command{
+			{Name: "model", Args: filepath.Join(sub, "model.gguf")},
+			{Name: "adapter", Args: "name=style " + filepath.Join(sub, "lora.gguf") + " 0.5"},
+			{Name: "tokenizer", Args: "@" + filepath.Join(sub, "tokenizer.json")},
+		}, modelfile.Commands)
+
+		// registry models are not paths
+		if err := os.WriteFile(filepath.Join(sub, "base"), []byte("FROM llama3\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		modelfile, err = ParseFileWithOptions(strings.NewReader("INCLUDE sub/base\n"), ParseOptions{BaseDir: dir, AllowInclude: true})
+		assert.NoError(t, err)
+		assert.Equal(t, []Command{{Name: "model", Args: "llama3"}}, modelfile.Commands)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := ParseFileWithOptions(strings.NewReader("FROM foo\nINCLUDE loop"), ParseOptions{BaseDir: dir, AllowInclude: true})
+		assert.ErrorIs(t, err, errIncludeCycle)
+	})
+}
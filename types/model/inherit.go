@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// maxInheritDepth is the maximum number of nested INHERIT commands.
+const maxInheritDepth = 8
+
+var (
+	errNoInheritResolver = errors.New("INHERIT requires an inherit resolver")
+	errInheritCycle      = errors.New("inherit cycle")
+	errInheritDepth      = fmt.Errorf("inherit chain is deeper than %d", maxInheritDepth)
+)
+
+// parseInherit resolves ref with opts.InheritResolver and parses the stored
+// Modelfile it returns. The commands are a base beneath the inheriting file.
+func parseInherit(ref string, opts ParseOptions) ([]Command, error) {
+	if opts.InheritResolver == nil {
+		return nil, fmt.Errorf("%w: %s", errNoInheritResolver, ref)
+	}
+
+	if slices.Contains(opts.inherits, ref) {
+		return nil, fmt.Errorf("%w: %s", errInheritCycle, ref)
+	}
+
+	if len(opts.inherits) >= maxInheritDepth {
+		return nil, fmt.Errorf("%w: %s", errInheritDepth, ref)
+	}
+
+	if opts.ctx != nil && opts.ctx.Err() != nil {
+		return nil, opts.ctx.Err()
+	}
+
+	r, err := opts.InheritResolver(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+
+	opts.AllowNoFrom = true
+	opts.inherits = append(slices.Clip(opts.inherits), ref)
+
+	modelfile, err := ParseFileWithOptions(r, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+
+	return modelfile.Commands, nil
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileInherit(t *testing.T) {
+	stored := map[string]string{
+		"myorg/model:v1": "FROM llama3\nSYSTEM You are a file parser.\nPARAMETER temperature 0.7\nPARAMETER num_ctx 4096\n",
+		"myorg/model:v2": "INHERIT myorg/model:v1\nPARAMETER num_ctx 8192\n",
+		"myorg/loop:a":   "INHERIT myorg/loop:b\n",
+		"myorg/loop:b":   "INHERIT myorg/loop:a\n",
+	}
+
+	resolver := func(ref string) (io.Reader, error) {
+		if strings.HasPrefix(ref, "myorg/deep:") {
+			var n int
+			fmt.Sscanf(ref, "myorg/deep:%d", &n)
+			return strings.NewReader(fmt.Sprintf("INHERIT myorg/deep:%d\n", n+1)), nil
+		}
+
+		s, ok := stored[ref]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+
+		return strings.NewReader(s), nil
+	}
+
+	var cases = []struct {
+		input    string
+		expected []Command
+		err      error
+	}{
+		{
+			"INHERIT myorg/model:v1\nPARAMETER temperature 0.2\n",
+			[]Command{
+				{Name: "model", Args: "llama3"},
+				{Name: "system", Args: "You are a file parser."},
+				{Name: "num_ctx", Args: "4096"},
+				{Name: "temperature", Args: "0.2"},
+			},
+			nil,
+		},
+		{
+			"INHERIT myorg/model:v2\nSYSTEM You are a careful file parser.\n",
+			[]Command{
+				{Name: "model", Args: "llama3"},
+				{Name: "temperature", Args: "0.7"},
+				{Name: "num_ctx", Args: "8192"},
+				{Name: "system", Args: "You are a careful file parser."},
+			},
+			nil,
+		},
+		{
+			"INHERIT myorg/loop:a\n",
+			nil,
+			errInheritCycle,
+		},
+		{
+			"INHERIT myorg/deep:0\n",
+			nil,
+			errInheritDepth,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), ParseOptions{InheritResolver: resolver})
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				assert.Equal(t, c.expected, modelfile.Commands)
+			}
+		})
+	}
+
+	_, err := ParseFile(strings.NewReader("INHERIT myorg/model:v1\n"))
+	assert.ErrorIs(t, err, errNoInheritResolver)
+}
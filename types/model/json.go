@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// commandJSON is the JSON form of a Command. Constraints are written as they
+// are in a Modelfile, e.g. "os=linux" or "mem>=8G". Extra is the value kind
+// of a command registered with ParseOptions.ExtraCommands, so that it is
+// formatted under its own keyword once decoded rather than as a parameter.
+type commandJSON struct {
+	Name        string   `json:"name"`
+	Args        string   `json:"args"`
+	Constraints []string `json:"constraints,omitempty"`
+	Weight      float64  `json:"weight,omitempty"`
+	Extra       string   `json:"extra,omitempty"`
+}
+
+// valueKindNames are the names of the value kinds of extra commands in their
+// JSON form.
+var valueKindNames = map[ValueKind]string{
+	ValueSingleLine: "single_line",
+	ValueMultiline:  "multiline",
+	ValueMessage:    "message",
+}
+
+// MarshalJSON encodes c as {"name": "...", "args": "..."}, with constraints,
+// weight and the value kind of extra commands included when set.
+func (c Command) MarshalJSON() ([]byte, error) {
+	v := commandJSON{Name: c.Name, Args: c.Args, Weight: c.Weight}
+	if c.spec != nil {
+		v.Extra = valueKindNames[c.spec.Value]
+	}
+
+	for _, constraint := range c.Constraints {
+		v.Constraints = append(v.Constraints, constraint.String())
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (c *Command) UnmarshalJSON(b []byte) error {
+	var v commandJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*c = Command{Name: v.Name, Args: v.Args, Weight: v.Weight}
+	if v.Extra != "" {
+		for kind, name := range valueKindNames {
+			if name == v.Extra {
+				c.spec = &CommandSpec{Value: kind}
+			}
+		}
+
+		if c.spec == nil {
+			return fmt.Errorf("unknown extra command value %q", v.Extra)
+		}
+	}
+
+	if len(v.Constraints) > 0 {
+		var err error
+		if c.Constraints, err = parseConstraints(strings.Join(v.Constraints, ",")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandJSON(t *testing.T) {
+	modelfile, err := ParseFile(strings.NewReader(`FROM llama3
+FROM llama3:70b [mem>=48G]
+PARAMETER stop "<|eot_id|>"
+SYSTEM """You are
+a file parser."""
+MESSAGE user[weight=2] Hey there!
+`))
+	assert.NoError(t, err)
+
+	b, err := json.Marshal(modelfile.Commands)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name": "model", "args": "llama3"},
+		{"name": "model", "args": "llama3:70b", "constraints": ["mem>=48G"]},
+		{"name": "stop", "args": "<|eot_id|>"},
+		{"name": "system", "args": "You are\na file parser."},
+		{"name": "message", "args": "user: Hey there!", "weight": 2}
+	]`, string(b))
+
+	var cmds []Command
+	assert.NoError(t, json.Unmarshal(b, &cmds))
+	assert.Equal(t, modelfile.Commands, cmds)
+
+	var cmd Command
+	assert.Error(t, json.Unmarshal([]byte(`{"name": "model", "args": "llama3", "constraints": ["bogus"]}`), &cmd))
+	assert.Error(t, json.Unmarshal([]byte(`{"name": "notes", "args": "hi", "extra": "bogus"}`), &cmd))
+}
+
+func TestCommandJSONExtraCommands(t *testing.T) {
+	modelfile, err := ParseFileWithOptions(strings.NewReader(`FROM llama3
+CAPABILITY tools
+README """
+# foo
+A model which parses files.
+"""
+EXAMPLE user Hey there!
+PARAMETER temperature 0.7
+`), ParseOptions{ExtraCommands: map[string]CommandSpec{
+		"capability": {Value: ValueSingleLine},
+		"readme":     {Value: ValueMultiline},
+		"example":    {Value: ValueMessage},
+	}})
+	assert.NoError(t, err)
+
+	b, err := json.Marshal(modelfile.Commands)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name": "model", "args": "llama3"},
+		{"name": "capability", "args": "tools", "extra": "single_line"},
+		{"name": "readme", "args": "\n# foo\nA model which parses files.\n", "extra": "multiline"},
+		{"name": "example", "args": "user: Hey there!", "extra": "message"},
+		{"name": "temperature", "args": "0.7"}
+	]`, string(b))
+
+	var cmds []Command
+	assert.NoError(t, json.Unmarshal(b, &cmds))
+	assert.Equal(t, modelfile.Commands, cmds)
+	assert.Equal(t, modelfile.String(), (&File{Commands: cmds}).String())
+}
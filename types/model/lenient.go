@@ -0,0 +1,51 @@
+package model
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// TryParse parses r on a best effort basis, skipping lines which fail to
+// parse, to help decide whether arbitrary text is a Modelfile. It returns the
+// commands which parsed and a confidence between 0 and 1: the fraction of
+// non-blank lines which were kept. A missing FROM line is not an error and
+// INCLUDE is never resolved. Only errors reading r are returned.
+func TryParse(r io.Reader) ([]Command, float64, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lines := strings.Split(string(b), "\n")
+
+	var total int
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			total++
+		}
+	}
+
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	// skipped holds the lines discarded by errors, which may overlap when
+	// several errors are found in one command
+	skipped := make(map[int]bool)
+	f, err := ParseFileWithOptions(bytes.NewReader(b), ParseOptions{
+		AllowNoFrom: true,
+		recover: func(err *ParseError, end int) {
+			for i := max(err.Line, 1); i <= min(end, len(lines)); i++ {
+				if strings.TrimSpace(lines[i-1]) != "" {
+					skipped[i] = true
+				}
+			}
+		},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return f.Commands, float64(total-len(skipped)) / float64(total), nil
+}
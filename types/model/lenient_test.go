@@ -0,0 +1,76 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryParse(t *testing.T) {
+	cmds, confidence, err := TryParse(strings.NewReader(`FROM llama3
+# sampling
+PARAMETER temperature 0.7
+SYSTEM """You are
+a file parser."""
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, confidence)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "system", Args: "You are\na file parser."},
+	}, cmds)
+
+	cmds, confidence, err = TryParse(strings.NewReader(`FROM llama3
+PARAMETER temperature warm
+SYSTEM You are a file parser.
+`))
+	assert.NoError(t, err)
+	assert.InDelta(t, 2.0/3, confidence, 1e-9)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "system", Args: "You are a file parser."},
+	}, cmds)
+
+	cmds, confidence, err = TryParse(strings.NewReader(`Dear team,
+
+Please find the quarterly report attached.
+Let me know if you have questions!
+Thanks,
+Sam
+`))
+	assert.NoError(t, err)
+	assert.Less(t, confidence, 0.5)
+	assert.Empty(t, cmds)
+
+	cmds, confidence, err = TryParse(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Zero(t, confidence)
+	assert.Empty(t, cmds)
+}
+
+func TestTryParseRecovers(t *testing.T) {
+	cmds, confidence, err := TryParse(strings.NewReader(`FROM llama3
+FROM mistral
+INCLUDE ./other.modelfile
+PARAMETER preset unknown
+PARAMETER temperature 0.7
+BOGUS line with words
+SYSTEM """You are
+a file parser."""
+`))
+	assert.NoError(t, err)
+	assert.InDelta(t, 4.0/8, confidence, 1e-9)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "system", Args: "You are\na file parser."},
+	}, cmds)
+
+	// an unterminated quote discards the rest of the input
+	cmds, confidence, err = TryParse(strings.NewReader("FROM llama3\nSYSTEM \"\"\"You are\na file parser.\n"))
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0/3, confidence, 1e-9)
+	assert.Equal(t, []Command{{Name: "model", Args: "llama3"}}, cmds)
+}
@@ -0,0 +1,459 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Diagnostic is an advisory finding about a Modelfile. Unlike parse errors,
+// diagnostics do not prevent a Modelfile from being used.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return d.Severity.String() + ": " + d.Message
+}
+
+// DefaultMaxSystemBytes is the size above which a SYSTEM prompt is reported
+// as unusually large.
+const DefaultMaxSystemBytes = 64 << 10
+
+// ValidateOptions configures Lint.
+type ValidateOptions struct {
+	// MaxSystemBytes is the size above which a SYSTEM prompt is reported as
+	// unusually large. Zero means DefaultMaxSystemBytes.
+	MaxSystemBytes int
+
+	// SupportedParamsResolver returns the parameters accepted by the base
+	// model ref. When set, parameters the base model does not accept are
+	// reported.
+	SupportedParamsResolver func(ref string) ([]string, error)
+
+	// HardwareResolver returns the hardware the model is expected to run
+	// on. When set, num_ctx and num_gpu settings which clearly exceed it are
+	// reported.
+	HardwareResolver func() (Hardware, error)
+}
+
+// Lint checks cmds for likely mistakes which are nonetheless valid syntax.
+func Lint(cmds []Command, opts ValidateOptions) []Diagnostic {
+	maxSystemBytes := opts.MaxSystemBytes
+	if maxSystemBytes <= 0 {
+		maxSystemBytes = DefaultMaxSystemBytes
+	}
+
+	var diags []Diagnostic
+	for _, cmd := range cmds {
+		switch cmd.Name {
+		case "system":
+			if len(cmd.Args) > maxSystemBytes {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("SYSTEM prompt is %d bytes; this is unusually large", len(cmd.Args)),
+				})
+			}
+		case "seed":
+			if v, err := ParseParameter(cmd.Name, cmd.Args); err == nil && v.(int64) == -1 {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityInfo,
+					Message:  "seed -1 uses a random seed for every request",
+				})
+			}
+		default:
+			if r, ok := parameterRanges[cmd.Name]; ok {
+				v, err := ParseParameter(cmd.Name, cmd.Args)
+				if err != nil {
+					continue
+				}
+
+				if f := float64(v.(float32)); f < r.min || f > r.max {
+					diags = append(diags, Diagnostic{
+						Severity: SeverityWarning,
+						Message:  fmt.Sprintf("%s %s is unusual (typical range %.1f-%.1f)", cmd.Name, cmd.Args, r.min, r.max),
+					})
+				}
+			}
+		}
+	}
+
+	diags = append(diags, lintDuplicateParameters(cmds)...)
+	diags = append(diags, lintTemplateRequirements(cmds)...)
+	diags = append(diags, lintSystemUnused(cmds)...)
+	diags = append(diags, lintEndTokens(cmds)...)
+	diags = append(diags, lintMergeWeights(cmds)...)
+	diags = append(diags, lintControlCharacters(cmds)...)
+	diags = append(diags, lintToolsTemplate(cmds)...)
+	if opts.SupportedParamsResolver != nil {
+		diags = append(diags, lintSupportedParameters(cmds, opts.SupportedParamsResolver)...)
+	}
+
+	if opts.HardwareResolver != nil {
+		diags = append(diags, lintHardware(cmds, opts.HardwareResolver)...)
+	}
+
+	return diags
+}
+
+// lintSystemUnused reports a SYSTEM prompt which the TEMPLATE ignores because
+// it references neither .System nor .Messages, which carries the system
+// prompt as a message. Modelfiles without a TEMPLATE use the template of the
+// base model, which cannot be checked here.
+func lintSystemUnused(cmds []Command) []Diagnostic {
+	var tmpl string
+	var system, hasTemplate bool
+	for _, cmd := range cmds {
+		switch cmd.Name {
+		case "template":
+			tmpl, hasTemplate = cmd.Args, true
+		case "system":
+			system = true
+		}
+	}
+
+	if !system || !hasTemplate {
+		return nil
+	}
+
+	fields, err := TemplateFields(tmpl)
+	if err != nil || slices.Contains(fields, "System") || slices.Contains(fields, "Messages") {
+		return nil
+	}
+
+	return []Diagnostic{{
+		Severity: SeverityWarning,
+		Message:  "SYSTEM is set but the template does not reference .System",
+	}}
+}
+
+// endTokenRe matches tokens which likely end a turn, e.g. <|im_end|>,
+// <|eot_id|>, <end_of_turn> and </s>.
+var endTokenRe = regexp.MustCompile(`<\|?[A-Za-z_]*(?:end|eot|eos)[A-Za-z_]*\|?>|</s>`)
+
+// lintEndTokens reports a TEMPLATE which emits end of turn tokens none of
+// which are stop sequences, as generation may run past the end of the turn.
+func lintEndTokens(cmds []Command) []Diagnostic {
+	var tmpl string
+	var stops []string
+	for _, cmd := range cmds {
+		switch cmd.Name {
+		case "template":
+			tmpl = cmd.Args
+		case "stop":
+			stops = append(stops, cmd.Args)
+		}
+	}
+
+	var tokens []string
+	for _, token := range endTokenRe.FindAllString(tmpl, -1) {
+		if slices.Contains(stops, token) {
+			return nil
+		}
+
+		if !slices.Contains(tokens, token) {
+			tokens = append(tokens, token)
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	return []Diagnostic{{
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("template emits %s but no matching stop parameter is set", strings.Join(tokens, ", ")),
+	}}
+}
+
+// toolFieldRe matches references to the fields a template needs to offer
+// tools to the model or to render its tool calls.
+var toolFieldRe = regexp.MustCompile(`\.(?:Tools|ToolCalls)\b`)
+
+// lintToolsTemplate reports a TEMPLATE which references neither .Tools nor
+// .ToolCalls in a Modelfile declaring the tools capability, as tool calling
+// will not work. Modelfiles without a TEMPLATE use the template of the base
+// model, which cannot be checked here.
+func lintToolsTemplate(cmds []Command) []Diagnostic {
+	var tmpl string
+	var hasTemplate bool
+	for _, cmd := range cmds {
+		if cmd.Name == "template" {
+			tmpl, hasTemplate = cmd.Args, true
+		}
+	}
+
+	if !hasTemplate || !hasCapability(cmds, "tools") {
+		return nil
+	}
+
+	tree, err := parseTemplate(tmpl)
+	if err != nil || toolFieldRe.MatchString(tree.Root.String()) {
+		return nil
+	}
+
+	return []Diagnostic{{
+		Severity: SeverityWarning,
+		Message:  "the tools capability is declared but the template does not reference .Tools or .ToolCalls",
+	}}
+}
+
+// lintMergeWeights reports MERGE weights which do not sum to 1.
+func lintMergeWeights(cmds []Command) []Diagnostic {
+	components, err := Merges(cmds)
+	if err != nil || len(components) == 0 {
+		return nil
+	}
+
+	var sum float64
+	for _, c := range components {
+		sum += c.Weight
+	}
+
+	if math.Abs(sum-1) <= 0.01 {
+		return nil
+	}
+
+	return []Diagnostic{{
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("MERGE weights sum to %s, not 1", strconv.FormatFloat(sum, 'f', -1, 64)),
+	}}
+}
+
+// lintControlCharacters reports MESSAGE, SYSTEM and TEMPLATE values which
+// contain invalid UTF-8 or control characters other than tab and newline, as
+// these can corrupt prompts. Only the first offending byte of each value is
+// reported. Lines are those of the canonical form of cmds.
+func lintControlCharacters(cmds []Command) []Diagnostic {
+	var diags []Diagnostic
+	for i, d := range Directives(cmds) {
+		switch cmds[i].Name {
+		case "message", "system", "template":
+		default:
+			continue
+		}
+
+		for j, r := range d.Value {
+			if r == utf8.RuneError {
+				if _, size := utf8.DecodeRuneInString(d.Value[j:]); size == 1 {
+					diags = append(diags, Diagnostic{
+						Severity: SeverityWarning,
+						Message:  fmt.Sprintf("line %d: %s contains invalid UTF-8 at offset %d", d.Line, d.Kind, j),
+					})
+					break
+				}
+			}
+
+			if unicode.IsControl(r) && r != '\t' && r != '\n' && !(r == '\r' && strings.HasPrefix(d.Value[j+1:], "\n")) {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("line %d: %s contains control character %U at offset %d", d.Line, d.Kind, r, j),
+				})
+				break
+			}
+		}
+	}
+
+	return diags
+}
+
+// lintSupportedParameters reports parameters which the base model does not
+// accept according to resolve. Each parameter is reported once.
+func lintSupportedParameters(cmds []Command, resolve func(string) ([]string, error)) []Diagnostic {
+	var ref string
+	for _, cmd := range cmds {
+		if cmd.Name == "model" {
+			ref = cmd.Args
+			break
+		}
+	}
+
+	if ref == "" {
+		return nil
+	}
+
+	supported, err := resolve(ref)
+	if err != nil {
+		return []Diagnostic{{
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("could not determine the parameters supported by %s: %v", ref, err),
+		}}
+	}
+
+	var diags []Diagnostic
+	seen := make(map[string]bool)
+	for _, cmd := range cmds {
+		if !cmd.isParameter() || seen[cmd.Name] || slices.Contains(supported, cmd.Name) {
+			continue
+		}
+
+		seen[cmd.Name] = true
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s is not supported by %s and will be ignored", cmd.Name, ref),
+		})
+	}
+
+	return diags
+}
+
+// lintTemplateRequirements reports parameters which do not satisfy the
+// requirements declared by the TEMPLATE, e.g. {{/* requires num_ctx>=4096 */}}.
+// Parameters which are not set are assumed to use a suitable default.
+func lintTemplateRequirements(cmds []Command) []Diagnostic {
+	var tmpl string
+	params := make(map[string]string)
+	for _, cmd := range cmds {
+		if cmd.Name == "variant" {
+			break
+		}
+
+		if len(cmd.Constraints) > 0 {
+			continue
+		}
+
+		switch {
+		case cmd.Name == "template":
+			tmpl = cmd.Args
+		case cmd.isParameter():
+			params[cmd.Name] = cmd.Args
+		}
+	}
+
+	var diags []Diagnostic
+	for _, req := range templateRequirements(tmpl) {
+		s, ok := params[req.Name]
+		if !ok {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || req.satisfiedBy(v) {
+			continue
+		}
+
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("TEMPLATE requires %s%s%s but %s is %s", req.Name, req.Op, strconv.FormatFloat(req.Value, 'f', -1, 64), req.Name, s),
+		})
+	}
+
+	return diags
+}
+
+// lintDuplicateParameters reports scalar parameters which are set more than
+// once. Only the last value takes effect. Commands with constraints and those
+// scoped to a VARIANT are expected to repeat parameters and are ignored.
+func lintDuplicateParameters(cmds []Command) []Diagnostic {
+	var names []string
+	values := make(map[string][]string)
+	for _, cmd := range cmds {
+		if cmd.Name == "variant" {
+			break
+		}
+
+		if !cmd.isParameter() || parameterKinds[cmd.Name] == reflect.Slice || len(cmd.Constraints) > 0 {
+			continue
+		}
+
+		if _, ok := values[cmd.Name]; !ok {
+			names = append(names, cmd.Name)
+		}
+
+		values[cmd.Name] = append(values[cmd.Name], cmd.Args)
+	}
+
+	var diags []Diagnostic
+	for _, name := range names {
+		v := values[name]
+		if len(v) < 2 {
+			continue
+		}
+
+		times := "twice"
+		if len(v) > 2 {
+			times = fmt.Sprintf("%d times", len(v))
+		}
+
+		if !slices.ContainsFunc(v, func(s string) bool { return s != v[0] }) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityInfo,
+				Message:  fmt.Sprintf("%s specified %s with the same value", name, times),
+			})
+			continue
+		}
+
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s specified %s with different values (%s); last wins", name, times, strings.Join(v, ", ")),
+		})
+	}
+
+	return diags
+}
+
+// secretPatterns matches common credential formats which should never be
+// shared in a Modelfile.
+var secretPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"an OpenAI API key", regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`)},
+	{"a GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`)},
+	{"a Hugging Face token", regexp.MustCompile(`\bhf_[A-Za-z0-9]{30,}`)},
+	{"an AWS access key", regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`)},
+	{"a Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"a Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"a private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+}
+
+// ScanSecrets reports values in cmds which look like credentials, such as API
+// keys or tokens. It is advisory and may report false positives.
+func ScanSecrets(cmds []Command) []Diagnostic {
+	var diags []Diagnostic
+	for _, cmd := range cmds {
+		switch cmd.Name {
+		case "model", "adapter", "license":
+			continue
+		}
+
+		for _, p := range secretPatterns {
+			if p.re.MatchString(cmd.Args) {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("%s appears to contain %s", cmd.Name, p.name),
+				})
+			}
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,269 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Manifest is the build context of a Modelfile: its canonical text plus every
+// local file it references.
+type Manifest struct {
+	Modelfile string
+	Files     []ManifestFile
+}
+
+// ManifestFile is a local file referenced by a Modelfile command.
+type ManifestFile struct {
+	// Command is the name of the command referencing the file, e.g. "model".
+	Command string
+	// Ref is the reference as written in the Modelfile, without any adapter
+	// alias or scale.
+	Ref string
+	// Path is the resolved path on disk.
+	Path string
+	Size int64
+}
+
+// ToBuildManifest resolves the local files referenced by cmds against baseDir
+// and returns the resulting build manifest. A directory, such as a safetensors
+// checkout given to FROM, contributes every file beneath it which is not
+// hidden, each with the directory as its Ref. Registry references and blobs
+// which have already been uploaded are not part of the manifest.
+func ToBuildManifest(cmds []Command, baseDir string) (*Manifest, error) {
+	m := Manifest{Modelfile: File{Commands: cmds}.String()}
+	for _, cmd := range cmds {
+		ref, ok := commandRef(cmd)
+		if !ok {
+			continue
+		}
+
+		path, ok := localPath(ref, baseDir)
+		if !ok {
+			continue
+		}
+
+		fi, err := os.Stat(path)
+		if os.IsNotExist(err) && cmd.Name == "model" && !strings.HasPrefix(ref, "@") && !isPathLike(ref) {
+			// not a local file so this must be a registry model
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if fi.IsDir() {
+			files, err := dirFiles(cmd.Name, ref, path)
+			if err != nil {
+				return nil, err
+			}
+
+			m.Files = append(m.Files, files...)
+			continue
+		}
+
+		m.Files = append(m.Files, ManifestFile{
+			Command: cmd.Name,
+			Ref:     ref,
+			Path:    path,
+			Size:    fi.Size(),
+		})
+	}
+
+	return &m, nil
+}
+
+// dirFiles returns a ManifestFile for every file beneath dir, skipping hidden
+// files and directories such as .git.
+func dirFiles(name, ref, dir string) ([]ManifestFile, error) {
+	var files []ManifestFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, ManifestFile{
+			Command: name,
+			Ref:     ref,
+			Path:    path,
+			Size:    fi.Size(),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// localPath resolves ref to a path relative to baseDir. It reports false for
+// references to blobs which have already been uploaded, e.g. @sha256:abc...,
+// and for inline adapters.
+func localPath(ref, baseDir string) (string, bool) {
+	if strings.HasPrefix(ref, "base64:") {
+		return "", false
+	}
+
+	if strings.HasPrefix(ref, "@") {
+		ref = ref[1:]
+		if strings.HasPrefix(ref, "sha256:") || strings.HasPrefix(ref, "sha256-") {
+			return "", false
+		}
+	}
+
+	if ref == "~" || strings.HasPrefix(ref, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+
+		return filepath.Join(home, ref[1:]), true
+	}
+
+	if filepath.IsAbs(ref) {
+		return ref, true
+	}
+
+	return filepath.Join(baseDir, ref), true
+}
+
+// isPathLike reports whether ref is written as a file path rather than a
+// registry model name.
+func isPathLike(ref string) bool {
+	switch {
+	case filepath.IsAbs(ref),
+		ref == "~", strings.HasPrefix(ref, "~/"),
+		ref == ".", ref == "..",
+		strings.HasPrefix(ref, "./"), strings.HasPrefix(ref, "../"):
+		return true
+	}
+
+	switch strings.ToLower(filepath.Ext(ref)) {
+	case ".gguf", ".bin", ".safetensors":
+		return true
+	}
+
+	return false
+}
+
+// RequiresNetwork reports whether cmds reference a model or adapter which
+// must be pulled from a registry. References to local files and uploaded blobs
+// do not require network access.
+func RequiresNetwork(cmds []Command) bool {
+	for _, cmd := range cmds {
+		if ref, ok := commandRef(cmd); ok && !strings.HasPrefix(ref, "@") && !strings.HasPrefix(ref, "base64:") && !isPathLike(ref) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var errSelfReference = errors.New("FROM refers to the model being created")
+
+// DetectSelfReference returns an error if a FROM in cmds refers to the model
+// targetName being created, which cannot be built from itself. Names are
+// compared case-insensitively after filling in the default host, namespace
+// and tag, so llama3 matches llama3:latest but not llama3:8b.
+func DetectSelfReference(cmds []Command, targetName string) error {
+	target := ParseName(targetName)
+	if !target.IsValid() {
+		return nil
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Name != "model" || isPathLike(cmd.Args) {
+			continue
+		}
+
+		if n := ParseName(cmd.Args); n.IsValid() && strings.EqualFold(n.String(), target.String()) {
+			return fmt.Errorf("%w: %s", errSelfReference, cmd.Args)
+		}
+	}
+
+	return nil
+}
+
+// canonicalizeTag appends the default tag to a registry reference which has
+// none, e.g. llama3 becomes llama3:latest. Paths, tagged references, digests
+// and invalid names are returned unchanged.
+func canonicalizeTag(ref string) string {
+	if isPathLike(ref) {
+		return ref
+	}
+
+	if n := ParseNameBare(ref); n.Tag != "" || n.RawDigest != "" || !ParseName(ref).IsValid() {
+		return ref
+	}
+
+	return ref + ":" + DefaultName().Tag
+}
+
+// commandRef returns the model or adapter referenced by cmd, without any
+// adapter alias or scale.
+func commandRef(cmd Command) (string, bool) {
+	switch cmd.Name {
+	case "model":
+		return cmd.Args, true
+	case "adapter":
+		adapter, err := ParseAdapter(cmd.Args)
+		if err != nil || adapter.Inline != nil {
+			return "", false
+		}
+
+		return adapter.Path, true
+	default:
+		return "", false
+	}
+}
+
+// ExternalFiles returns, in order, the local files referenced by cmds: @path
+// values of TEMPLATE, SYSTEM and TOKENIZER and local FROM and ADAPTER paths.
+// Paths are returned as written, without the leading @. Registry references
+// and uploaded blobs are not included.
+func ExternalFiles(cmds []Command) []string {
+	var files []string
+	for _, cmd := range cmds {
+		var ref string
+		switch cmd.Name {
+		case "template", "system", "tokenizer":
+			if !strings.HasPrefix(cmd.Args, "@") || strings.ContainsAny(cmd.Args, " \t\r\n") {
+				continue
+			}
+
+			ref = cmd.Args
+		default:
+			var ok bool
+			if ref, ok = commandRef(cmd); !ok || !strings.HasPrefix(ref, "@") && !isPathLike(ref) {
+				continue
+			}
+		}
+
+		if _, ok := localPath(ref, ""); ok {
+			files = append(files, strings.TrimPrefix(ref, "@"))
+		}
+	}
+
+	return files
+}
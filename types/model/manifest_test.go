@@ -0,0 +1,208 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToBuildManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "model.gguf"), []byte("model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "adapter.bin"), []byte("adapter!"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("local", func(t *testing.T) {
+		cmds := []Command{
+			{Name: "model", Args: "model.gguf"},
+			{Name: "adapter", Args: "name=mylora @adapter.bin 50%"},
+			{Name: "temperature", Args: "1"},
+		}
+
+		m, err := ToBuildManifest(cmds, dir)
+		assert.NoError(t, err)
+		assert.Equal(t, "FROM model.gguf\nADAPTER name=mylora @adapter.bin 50%\nPARAMETER temperature 1\n", m.Modelfile)
+		assert.Equal(t, []ManifestFile{
+			{Command: "model", Ref: "model.gguf", Path: filepath.Join(dir, "model.gguf"), Size: 5},
+			{Command: "adapter", Ref: "@adapter.bin", Path: filepath.Join(dir, "adapter.bin"), Size: 8},
+		}, m.Files)
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		cmds := []Command{
+			{Name: "model", Args: "llama3:latest"},
+			{Name: "adapter", Args: "@sha256:abc"},
+			{Name: "adapter", Args: "base64:aGVsbG8="},
+		}
+
+		m, err := ToBuildManifest(cmds, dir)
+		assert.NoError(t, err)
+		assert.Empty(t, m.Files)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		st := filepath.Join(dir, "safetensors")
+		for _, name := range []string{"model.safetensors", "tokenizer.json", ".git/HEAD", ".hidden"} {
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(st, name)), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filepath.Join(st, name), []byte(name), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		m, err := ToBuildManifest([]Command{{Name: "model", Args: "./safetensors"}}, dir)
+		assert.NoError(t, err)
+		assert.Equal(t, []ManifestFile{
+			{Command: "model", Ref: "./safetensors", Path: filepath.Join(st, "model.safetensors"), Size: 17},
+			{Command: "model", Ref: "./safetensors", Path: filepath.Join(st, "tokenizer.json"), Size: 14},
+		}, m.Files)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := ToBuildManifest([]Command{{Name: "model", Args: "./missing.gguf"}}, dir)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		_, err = ToBuildManifest([]Command{{Name: "model", Args: "foo"}, {Name: "adapter", Args: "missing.bin"}}, dir)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestRequiresNetwork(t *testing.T) {
+	var cases = []struct {
+		cmds     []Command
+		expected bool
+	}{
+		{[]Command{{Name: "model", Args: "llama3"}}, true},
+		{[]Command{{Name: "model", Args: "registry.ollama.ai/library/llama3:latest"}}, true},
+		{[]Command{{Name: "model", Args: "./model.gguf"}, {Name: "adapter", Args: "lora.bin"}}, false},
+		{[]Command{{Name: "model", Args: "/path/to/model"}, {Name: "adapter", Args: "~/lora"}}, false},
+		{[]Command{{Name: "model", Args: "@sha256:abc"}, {Name: "system", Args: "llama3"}}, false},
+		{[]Command{{Name: "model", Args: "@sha256:abc"}, {Name: "adapter", Args: "base64:aGVsbG8="}}, false},
+		{[]Command{{Name: "model", Args: "./model.gguf"}, {Name: "adapter", Args: "myorg/lora"}}, true},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, c.expected, RequiresNetwork(c.cmds))
+		})
+	}
+}
+
+func TestExternalFiles(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []string
+	}{
+		{
+			`FROM ./model.gguf
+ADAPTER name=a ./adapter.gguf 0.5
+ADAPTER registry/adapter
+TEMPLATE @prompt.tmpl
+SYSTEM @system.txt
+SYSTEM """@mentions are allowed here"""
+PARAMETER stop @
+`,
+			[]string{"./model.gguf", "./adapter.gguf", "prompt.tmpl", "system.txt"},
+		},
+		{
+			`FROM @sha256:abc
+ADAPTER base64:aGVsbG8=
+`,
+			nil,
+		},
+		{
+			`FROM llama3
+SYSTEM You are a helpful assistant.
+`,
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c.input))
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, ExternalFiles(modelfile.Commands))
+		})
+	}
+}
+
+func TestParseFileRejectDuplicateRefs(t *testing.T) {
+	var cases = []struct {
+		input string
+		err   error
+	}{
+		{"FROM ./model.gguf\nADAPTER ./a.gguf\nADAPTER ./b.gguf", nil},
+		{"FROM llama3\nFROM llama3 [os=linux]", nil},
+		{"FROM ./model.gguf\nADAPTER ./model.gguf", errDuplicateRef},
+		{"FROM ./model.gguf\nADAPTER name=a ./a.gguf\nADAPTER name=b a.gguf 0.5", errDuplicateRef},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			_, err := ParseFileWithOptions(strings.NewReader(c.input), ParseOptions{RejectDuplicateRefs: true})
+			assert.ErrorIs(t, err, c.err)
+
+			_, err = ParseFile(strings.NewReader(c.input))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseFileCanonicalizeTags(t *testing.T) {
+	var cases = []struct {
+		input, expected string
+	}{
+		{"llama3", "llama3:latest"},
+		{"library/llama3", "library/llama3:latest"},
+		{"registry.example.com:5000/team/llama3", "registry.example.com:5000/team/llama3:latest"},
+		{"llama3:8b", "llama3:8b"},
+		{"llama3@sha256:" + strings.Repeat("a", 64), "llama3@sha256:" + strings.Repeat("a", 64)},
+		{"./model.gguf", "./model.gguf"},
+		{"/models/llama3", "/models/llama3"},
+		{"model.safetensors", "model.safetensors"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader("FROM "+c.input), ParseOptions{CanonicalizeTags: true})
+			assert.NoError(t, err)
+			assert.Equal(t, []Command{{Name: "model", Args: c.expected}}, modelfile.Commands)
+
+			modelfile, err = ParseFile(strings.NewReader("FROM " + c.input))
+			assert.NoError(t, err)
+			assert.Equal(t, []Command{{Name: "model", Args: c.input}}, modelfile.Commands)
+		})
+	}
+}
+
+func TestDetectSelfReference(t *testing.T) {
+	var cases = []struct {
+		from, target string
+		err          error
+	}{
+		{"mymodel", "mymodel", errSelfReference},
+		{"mymodel", "mymodel:latest", errSelfReference},
+		{"MyModel:Latest", "registry.ollama.ai/library/mymodel", errSelfReference},
+		{"mymodel:7b", "mymodel", nil},
+		{"mymodel", "mymodel:7b", nil},
+		{"llama3", "mymodel", nil},
+		{"./mymodel", "mymodel", nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.from+" "+c.target, func(t *testing.T) {
+			cmds := []Command{{Name: "model", Args: c.from}, {Name: "temperature", Args: "0.7"}}
+			assert.ErrorIs(t, DetectSelfReference(cmds, c.target), c.err)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// MergeCommands overlays the commands in overlay on top of base. Every command in
+// overlay replaces all commands of the same name in base so, for example, a
+// PARAMETER stop in overlay replaces every stop sequence in base and a MESSAGE
+// in overlay replaces the entire seeded conversation. Commands not mentioned
+// in overlay are kept in their original order.
+func MergeCommands(base, overlay []Command) []Command {
+	names := make(map[string]bool)
+	for _, cmd := range overlay {
+		names[cmd.Name] = true
+	}
+
+	var merged []Command
+	for _, cmd := range base {
+		if !names[cmd.Name] {
+			merged = append(merged, cmd)
+		}
+	}
+
+	return append(merged, overlay...)
+}
+
+// ParseLayered parses each of files from fsys and merges them in order so that
+// later files override earlier ones. Only the first file is required to
+// contain a FROM command.
+func ParseLayered(fsys fs.FS, files ...string) ([]Command, error) {
+	var cmds []Command
+	for i, name := range files {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		modelfile, err := ParseFileWithOptions(f, ParseOptions{AllowNoFrom: i > 0})
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		cmds = MergeCommands(cmds, modelfile.Commands)
+	}
+
+	return cmds, nil
+}
+
+// DedupeLicense removes license commands which exactly duplicate the license
+// command immediately before them, as commonly happens when Modelfiles are
+// concatenated. Differing or non-adjacent licenses are kept.
+func DedupeLicense(cmds []Command) []Command {
+	deduped := make([]Command, 0, len(cmds))
+	for i, cmd := range cmds {
+		if i > 0 && cmd.Name == "license" && cmds[i-1].Name == "license" && cmds[i-1].Args == cmd.Args {
+			continue
+		}
+
+		deduped = append(deduped, cmd)
+	}
+
+	return deduped
+}
+
+// Minimize removes the commands in cmds which would not change the model
+// compared to base: parameters, templates and system prompts whose values are
+// identical to those base already has. Parameters are compared by their typed
+// value so "0.70" and "0.7" are considered equal. A nil base has no commands
+// so cmds is returned unchanged.
+func Minimize(cmds []Command, base *File) []Command {
+	if base == nil {
+		return cmds
+	}
+
+	values := func(cmds []Command) map[string][]any {
+		values := make(map[string][]any)
+		for _, cmd := range cmds {
+			switch {
+			case cmd.Name == "template", cmd.Name == "system", cmd.isParameter():
+			default:
+				continue
+			}
+
+			v, err := ParseParameter(cmd.Name, cmd.Args)
+			if err != nil {
+				v = cmd.Args
+			}
+
+			values[cmd.Name] = append(values[cmd.Name], v)
+		}
+
+		return values
+	}
+
+	have, want := values(base.Commands), values(cmds)
+
+	var minimized []Command
+	for _, cmd := range cmds {
+		if v, ok := want[cmd.Name]; ok && slices.Equal(v, have[cmd.Name]) {
+			continue
+		}
+
+		minimized = append(minimized, cmd)
+	}
+
+	return minimized
+}
+
+// Variants splits cmds into named presets declared with VARIANT. Commands
+// before the first VARIANT are shared by every variant and the commands
+// following a VARIANT override them, using the semantics of MergeCommands.
+func Variants(cmds []Command) map[string][]Command {
+	var base []Command
+	overrides := make(map[string][]Command)
+
+	var variant string
+	for _, cmd := range cmds {
+		switch {
+		case cmd.Name == "variant":
+			variant = cmd.Args
+			if _, ok := overrides[variant]; !ok {
+				overrides[variant] = []Command{}
+			}
+		case variant == "":
+			base = append(base, cmd)
+		default:
+			overrides[variant] = append(overrides[variant], cmd)
+		}
+	}
+
+	variants := make(map[string][]Command, len(overrides))
+	for name, cmds := range overrides {
+		variants[name] = MergeCommands(base, cmds)
+	}
+
+	return variants
+}
+
+// EffectiveConfig returns the configuration the model built from cmds runs
+// with: cmds plus the TEMPLATE, SYSTEM and parameters of base which cmds does
+// not set. As with MergeCommands, a command in cmds replaces every command of
+// the same name in base. It returns an error if a parameter value is invalid.
+func EffectiveConfig(cmds []Command, base *File) (*File, error) {
+	var inherited []Command
+	if base != nil {
+		for _, cmd := range base.Commands {
+			if cmd.Name == "template" || cmd.Name == "system" || cmd.isParameter() {
+				inherited = append(inherited, cmd)
+			}
+		}
+	}
+
+	merged := MergeCommands(inherited, cmds)
+	for _, cmd := range merged {
+		if cmd.isParameter() {
+			if _, err := ParseParameter(cmd.Name, cmd.Args); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &File{Commands: merged}, nil
+}
+
+// canonicalOrder is the rank of each command in the order produced by
+// sortCanonical. Parameters, which are named after themselves, rank between
+// FROM and TEMPLATE and commands which are not listed rank after MESSAGE.
+var canonicalOrder = map[string]int{
+	"model":    0,
+	"template": 2,
+	"system":   3,
+	"adapter":  4,
+	"license":  5,
+	"message":  6,
+}
+
+// sortCanonical returns cmds ordered by canonicalOrder. Commands following
+// the first VARIANT are not reordered.
+func sortCanonical(cmds []Command) []Command {
+	rank := func(cmd Command) int {
+		if r, ok := canonicalOrder[cmd.Name]; ok {
+			return r
+		} else if cmd.isParameter() {
+			return 1
+		}
+
+		return len(canonicalOrder) + 1
+	}
+
+	shared := len(cmds)
+	if i := slices.IndexFunc(cmds, func(cmd Command) bool { return cmd.Name == "variant" }); i >= 0 {
+		shared = i
+	}
+
+	sorted := slices.Clone(cmds)
+	slices.SortStableFunc(sorted[:shared], func(a, b Command) int { return rank(a) - rank(b) })
+	return sorted
+}
+
+// GroupByName groups cmds by their name, e.g. every stop parameter under
+// "stop" and every MESSAGE under "message". Commands keep their relative
+// order within a group.
+func GroupByName(cmds []Command) map[string][]Command {
+	groups := make(map[string][]Command)
+	for _, cmd := range cmds {
+		groups[cmd.Name] = append(groups[cmd.Name], cmd)
+	}
+
+	return groups
+}
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeCommands(t *testing.T) {
+	base := []Command{
+		{Name: "model", Args: "foo"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "stop", Args: "<|a|>"},
+		{Name: "stop", Args: "<|b|>"},
+		{Name: "system", Args: "You are a file parser."},
+	}
+
+	overlay := []Command{
+		{Name: "stop", Args: "<|c|>"},
+		{Name: "top_k", Args: "10"},
+	}
+
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "foo"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "system", Args: "You are a file parser."},
+		{Name: "stop", Args: "<|c|>"},
+		{Name: "top_k", Args: "10"},
+	}, MergeCommands(base, overlay))
+}
+
+func TestParseLayered(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Modelfile": {Data: []byte(`
+FROM foo
+PARAMETER temperature 0.7
+PARAMETER top_k 40
+SYSTEM You are a file parser.
+`)},
+		"dev.Modelfile": {Data: []byte(`
+PARAMETER temperature 1.0
+PARAMETER num_ctx 4096
+`)},
+		"prod.Modelfile": {Data: []byte(`
+PARAMETER temperature 0.2
+SYSTEM You are a careful file parser.
+`)},
+	}
+
+	cmds, err := ParseLayered(fsys, "Modelfile", "dev.Modelfile", "prod.Modelfile")
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "foo"},
+		{Name: "top_k", Args: "40"},
+		{Name: "num_ctx", Args: "4096"},
+		{Name: "temperature", Args: "0.2"},
+		{Name: "system", Args: "You are a careful file parser."},
+	}, cmds)
+
+	_, err = ParseLayered(fsys, "dev.Modelfile", "prod.Modelfile")
+	assert.ErrorIs(t, err, ErrMissingFrom)
+
+	_, err = ParseLayered(fsys, "Modelfile", "missing.Modelfile")
+	assert.Error(t, err)
+}
+
+func TestDedupeLicense(t *testing.T) {
+	var cases = []struct {
+		cmds     []Command
+		expected []Command
+	}{
+		{
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "license", Args: "MIT"},
+				{Name: "license", Args: "MIT"},
+				{Name: "license", Args: "MIT"},
+			},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "license", Args: "MIT"},
+			},
+		},
+		{
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "license", Args: "MIT"},
+				{Name: "license", Args: "Apache-2.0"},
+			},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "license", Args: "MIT"},
+				{Name: "license", Args: "Apache-2.0"},
+			},
+		},
+		{
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "license", Args: "MIT"},
+				{Name: "system", Args: "You are a file parser."},
+				{Name: "license", Args: "MIT"},
+			},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "license", Args: "MIT"},
+				{Name: "system", Args: "You are a file parser."},
+				{Name: "license", Args: "MIT"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, c.expected, DedupeLicense(c.cmds))
+		})
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	base := &File{Commands: []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "template", Args: "{{ .Prompt }}"},
+		{Name: "system", Args: "You are a file parser."},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "num_ctx", Args: "2048"},
+		{Name: "stop", Args: "<|a|>"},
+		{Name: "stop", Args: "<|b|>"},
+	}}
+
+	cmds := []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "template", Args: "{{ .Prompt }}"},
+		{Name: "system", Args: "You are a careful file parser."},
+		{Name: "temperature", Args: "0.70"},
+		{Name: "num_ctx", Args: "4096"},
+		{Name: "stop", Args: "<|a|>"},
+		{Name: "stop", Args: "<|b|>"},
+		{Name: "top_k", Args: "10"},
+		{Name: "message", Args: "user: Hey there!"},
+	}
+
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "system", Args: "You are a careful file parser."},
+		{Name: "num_ctx", Args: "4096"},
+		{Name: "top_k", Args: "10"},
+		{Name: "message", Args: "user: Hey there!"},
+	}, Minimize(cmds, base))
+
+	// a subset of stop sequences changes the model
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "stop", Args: "<|a|>"},
+	}, Minimize([]Command{{Name: "model", Args: "llama3"}, {Name: "stop", Args: "<|a|>"}}, base))
+
+	// there is nothing to remove without a base
+	assert.Equal(t, cmds, Minimize(cmds, nil))
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	base := &File{Commands: []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "template", Args: "{{ .Prompt }}"},
+		{Name: "system", Args: "You are a file parser."},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "stop", Args: "<|a|>"},
+		{Name: "stop", Args: "<|b|>"},
+		{Name: "message", Args: "user: Hey there!"},
+	}}
+
+	modelfile, err := EffectiveConfig([]Command{
+		{Name: "model", Args: "mymodel"},
+		{Name: "system", Args: "You are a careful file parser."},
+		{Name: "stop", Args: "<|c|>"},
+	}, base)
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "template", Args: "{{ .Prompt }}"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "model", Args: "mymodel"},
+		{Name: "system", Args: "You are a careful file parser."},
+		{Name: "stop", Args: "<|c|>"},
+	}, modelfile.Commands)
+
+	modelfile, err = EffectiveConfig([]Command{{Name: "model", Args: "mymodel"}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{{Name: "model", Args: "mymodel"}}, modelfile.Commands)
+
+	_, err = EffectiveConfig([]Command{{Name: "temperature", Args: "hot"}}, base)
+	assert.Error(t, err)
+}
+
+func TestVariants(t *testing.T) {
+	input := `
+FROM foo
+PARAMETER temperature 0.7
+PARAMETER top_k 40
+SYSTEM You are a file parser.
+
+VARIANT creative
+PARAMETER temperature 1.2
+PARAMETER top_p 0.95
+
+VARIANT precise
+PARAMETER temperature 0.1
+`
+
+	modelfile, err := ParseFile(strings.NewReader(input))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string][]Command{
+		"creative": {
+			{Name: "model", Args: "foo"},
+			{Name: "top_k", Args: "40"},
+			{Name: "system", Args: "You are a file parser."},
+			{Name: "temperature", Args: "1.2"},
+			{Name: "top_p", Args: "0.95"},
+		},
+		"precise": {
+			{Name: "model", Args: "foo"},
+			{Name: "top_k", Args: "40"},
+			{Name: "system", Args: "You are a file parser."},
+			{Name: "temperature", Args: "0.1"},
+		},
+	}, Variants(modelfile.Commands))
+
+	assert.Empty(t, Variants([]Command{{Name: "model", Args: "foo"}}))
+
+	_, err = ParseFile(strings.NewReader("FROM foo\nVARIANT \"\"\"\ncreative\n\"\"\""))
+	assert.ErrorIs(t, err, errMultilineValue)
+}
+
+func TestGroupByName(t *testing.T) {
+	cmds := []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "stop", Args: "<|a|>"},
+		{Name: "message", Args: "user: Hey there!"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "stop", Args: "<|b|>"},
+		{Name: "message", Args: "assistant: Hello!"},
+		{Name: "stop", Args: "<|c|>"},
+	}
+
+	assert.Equal(t, map[string][]Command{
+		"model":       {{Name: "model", Args: "llama3"}},
+		"temperature": {{Name: "temperature", Args: "0.7"}},
+		"stop": {
+			{Name: "stop", Args: "<|a|>"},
+			{Name: "stop", Args: "<|b|>"},
+			{Name: "stop", Args: "<|c|>"},
+		},
+		"message": {
+			{Name: "message", Args: "user: Hey there!"},
+			{Name: "message", Args: "assistant: Hello!"},
+		},
+	}, GroupByName(cmds))
+
+	assert.Empty(t, GroupByName(nil))
+}
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var errInvalidMessageWeight = errors.New("message weight must be a positive number")
+
+// Message is a message of the seeded conversation.
+type Message struct {
+	Role    string
+	Content string
+
+	// Weight is the importance of the message when selecting few-shot
+	// examples. It defaults to 1.
+	Weight float64
+}
+
+// Messages returns the messages declared in cmds.
+func Messages(cmds []Command) []Message {
+	var messages []Message
+	for _, cmd := range cmds {
+		if cmd.Name != "message" {
+			continue
+		}
+
+		role, content, _ := strings.Cut(cmd.Args, ": ")
+		m := Message{Role: role, Content: content, Weight: cmd.Weight}
+		if m.Weight == 0 {
+			m.Weight = 1
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages
+}
+
+// Conversation returns the seeded conversation of cmds in order. The SYSTEM
+// prompt, if any, leads the conversation as a system message unless a MESSAGE
+// already sets the system role. An invalid message role is an error.
+func Conversation(cmds []Command) ([]Message, error) {
+	var system string
+	var hasSystem bool
+	for _, cmd := range cmds {
+		if cmd.Name == "system" {
+			system, hasSystem = cmd.Args, true
+		}
+	}
+
+	messages := Messages(cmds)
+	for _, m := range messages {
+		if !isValidMessageRole(m.Role) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRole, m.Role)
+		}
+
+		if m.Role == "system" {
+			hasSystem = false
+		}
+	}
+
+	if hasSystem {
+		messages = append([]Message{{Role: "system", Content: system, Weight: 1}}, messages...)
+	}
+
+	return messages, nil
+}
+
+// parseMessageRole parses the role of a MESSAGE and its optional weight, e.g.
+// user[weight=2]. Roles are case-insensitive and returned in lower case.
+func parseMessageRole(s string) (string, float64, error) {
+	role, opts, ok := strings.Cut(s, "[")
+	role = strings.ToLower(role)
+	if !isValidMessageRole(role) {
+		return "", 0, ErrInvalidRole
+	}
+
+	if !ok {
+		return role, 0, nil
+	}
+
+	value, ok := strings.CutPrefix(opts, "weight=")
+	if !ok || !strings.HasSuffix(value, "]") {
+		return "", 0, fmt.Errorf("%w: %s", errInvalidMessageWeight, s)
+	}
+
+	weight, err := strconv.ParseFloat(strings.TrimSuffix(value, "]"), 64)
+	if err != nil || math.IsNaN(weight) || weight <= 0 || math.IsInf(weight, 0) {
+		return "", 0, fmt.Errorf("%w: %s", errInvalidMessageWeight, s)
+	}
+
+	return role, weight, nil
+}
+
+// MessagesFromJSON reads a JSON array of chat messages in the OpenAI format,
+// e.g. [{"role": "user", "content": "..."}], and converts each entry into a
+// message command.
+func MessagesFromJSON(r io.Reader) ([]Command, error) {
+	var messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&messages); err != nil {
+		return nil, err
+	}
+
+	cmds := make([]Command, 0, len(messages))
+	for _, m := range messages {
+		if !isValidMessageRole(m.Role) {
+			return nil, ErrInvalidRole
+		}
+
+		cmds = append(cmds, Command{Name: "message", Args: m.Role + ": " + m.Content})
+	}
+
+	return cmds, nil
+}
+
+// ValidateConversation reports likely mistakes in the order of the seeded
+// conversation: a conversation starting with an assistant message and
+// consecutive messages from the same role. System messages may appear
+// anywhere and tool messages are exempt from ordering.
+func ValidateConversation(cmds []Command) []Diagnostic {
+	var diags []Diagnostic
+	var prev string
+	for _, cmd := range cmds {
+		if cmd.Name != "message" {
+			continue
+		}
+
+		role, _, _ := strings.Cut(cmd.Args, ": ")
+		switch {
+		case role == "system":
+			continue
+		case role == "tool":
+		case prev == "" && role == "assistant":
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Message:  "conversation starts with an assistant message",
+			})
+		case role == prev:
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("consecutive %s messages", role),
+			})
+		}
+
+		prev = role
+	}
+
+	return diags
+}
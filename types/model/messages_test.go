@@ -0,0 +1,154 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessagesFromJSON(t *testing.T) {
+	input := `[
+	{"role": "system", "content": "You are a file parser."},
+	{"role": "user", "content": "Hey there!"},
+	{"role": "assistant", "content": "Hello!\nI want to parse all the things."}
+]`
+
+	cmds, err := MessagesFromJSON(strings.NewReader(input))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Command{
+		{Name: "message", Args: "system: You are a file parser."},
+		{Name: "message", Args: "user: Hey there!"},
+		{Name: "message", Args: "assistant: Hello!\nI want to parse all the things."},
+	}, cmds)
+
+	modelfile := File{Commands: append([]Command{{Name: "model", Args: "foo"}}, cmds...)}
+	assert.Equal(t, `FROM foo
+MESSAGE system You are a file parser.
+MESSAGE user Hey there!
+MESSAGE assistant "Hello!
+I want to parse all the things."
+`, modelfile.String())
+
+	modelfile2, err := ParseFile(strings.NewReader(modelfile.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, modelfile.Commands, modelfile2.Commands)
+}
+
+func TestConversation(t *testing.T) {
+	modelfile, err := ParseFile(strings.NewReader(`FROM foo
+SYSTEM You are a file parser.
+MESSAGE user Hey there!
+MESSAGE assistant Hello!
+`))
+	assert.NoError(t, err)
+
+	messages, err := Conversation(modelfile.Commands)
+	assert.NoError(t, err)
+	assert.Equal(t, []Message{
+		{Role: "system", Content: "You are a file parser.", Weight: 1},
+		{Role: "user", Content: "Hey there!", Weight: 1},
+		{Role: "assistant", Content: "Hello!", Weight: 1},
+	}, messages)
+
+	modelfile, err = ParseFile(strings.NewReader(`FROM foo
+SYSTEM You are a file parser.
+MESSAGE system Always parse things.
+MESSAGE user Hey there!
+`))
+	assert.NoError(t, err)
+
+	messages, err = Conversation(modelfile.Commands)
+	assert.NoError(t, err)
+	assert.Equal(t, []Message{
+		{Role: "system", Content: "Always parse things.", Weight: 1},
+		{Role: "user", Content: "Hey there!", Weight: 1},
+	}, messages)
+
+	messages, err = Conversation([]Command{{Name: "model", Args: "foo"}, {Name: "message", Args: "system: Always parse things."}})
+	assert.NoError(t, err)
+	assert.Equal(t, []Message{{Role: "system", Content: "Always parse things.", Weight: 1}}, messages)
+
+	_, err = Conversation([]Command{{Name: "message", Args: "moderator: hi"}})
+	assert.ErrorIs(t, err, ErrInvalidRole)
+}
+
+func TestMessagesFromJSONInvalid(t *testing.T) {
+	_, err := MessagesFromJSON(strings.NewReader(`[{"role": "moderator", "content": "hi"}]`))
+	assert.ErrorIs(t, err, ErrInvalidRole)
+
+	_, err = MessagesFromJSON(strings.NewReader(`{"role": "user"}`))
+	assert.Error(t, err)
+}
+
+func TestValidateConversation(t *testing.T) {
+	var cases = []struct {
+		messages []string
+		expected []Diagnostic
+	}{
+		{
+			[]string{"system: You are a file parser.", "user: Hey there!", "assistant: Hello!", "user: Parse this.", "assistant: Done."},
+			nil,
+		},
+		{
+			[]string{"user: Hey there!", "assistant: Hello!", "assistant: How can I help?"},
+			[]Diagnostic{{Severity: SeverityWarning, Message: "consecutive assistant messages"}},
+		},
+		{
+			[]string{"system: You are a file parser.", "assistant: Hello!", "user: Hey there!"},
+			[]Diagnostic{{Severity: SeverityWarning, Message: "conversation starts with an assistant message"}},
+		},
+		{
+			[]string{"user: What is 6 times 7?", "assistant: Let me check.", "tool: 42", "assistant: It is 42."},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			cmds := []Command{{Name: "model", Args: "foo"}}
+			for _, m := range c.messages {
+				cmds = append(cmds, Command{Name: "message", Args: m})
+			}
+
+			assert.Equal(t, c.expected, ValidateConversation(cmds))
+		})
+	}
+}
+
+func TestParseFileMessageWeight(t *testing.T) {
+	input := `FROM foo
+MESSAGE user[weight=2] Is Toronto in Canada?
+MESSAGE assistant yes
+MESSAGE user[weight=0.5] Is Sacramento in Canada?
+`
+
+	modelfile, err := ParseFile(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Message{
+		{Role: "user", Content: "Is Toronto in Canada?", Weight: 2},
+		{Role: "assistant", Content: "yes", Weight: 1},
+		{Role: "user", Content: "Is Sacramento in Canada?", Weight: 0.5},
+	}, Messages(modelfile.Commands))
+	assert.Equal(t, input, modelfile.String())
+
+	var cases = []string{
+		"FROM foo\nMESSAGE user[weight=heavy] hi",
+		"FROM foo\nMESSAGE user[weight=-1] hi",
+		"FROM foo\nMESSAGE user[weight=0] hi",
+		"FROM foo\nMESSAGE user[weight=NaN] hi",
+		"FROM foo\nMESSAGE user[weight=Inf] hi",
+		"FROM foo\nMESSAGE user[priority=1] hi",
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseFile(strings.NewReader(c))
+			assert.ErrorIs(t, err, errInvalidMessageWeight)
+		})
+	}
+
+	_, err = ParseFile(strings.NewReader("FROM foo\nMESSAGE badrole[weight=1] hi"))
+	assert.ErrorIs(t, err, ErrInvalidRole)
+}
@@ -0,0 +1,263 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+// parameterKinds maps each PARAMETER name to the kind of value it holds. It is
+// derived from the json tags of api.Options so the two never drift apart.
+var parameterKinds = func() map[string]reflect.Kind {
+	kinds := make(map[string]reflect.Kind)
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(api.Options{})) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" {
+			kinds[name] = field.Type.Kind()
+		}
+	}
+
+	return kinds
+}()
+
+// parameterDefaults maps each scalar PARAMETER name to its default value in
+// the typed form returned by ParseParameter.
+var parameterDefaults = func() map[string]any {
+	defaults := make(map[string]any)
+	v := reflect.ValueOf(api.DefaultOptions())
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			continue
+		}
+
+		switch f := v.FieldByIndex(field.Index); f.Kind() {
+		case reflect.Float32, reflect.Float64:
+			defaults[name] = float32(f.Float())
+		case reflect.Int:
+			defaults[name] = f.Int()
+		case reflect.Bool:
+			defaults[name] = f.Bool()
+		case reflect.String:
+			defaults[name] = f.String()
+		}
+	}
+
+	return defaults
+}()
+
+// parameterRanges holds the typical range of parameters where values outside
+// of it, while valid, are almost certainly a mistake.
+var parameterRanges = map[string]struct{ min, max float64 }{
+	"repeat_penalty":    {1.0, 1.3},
+	"frequency_penalty": {0.0, 2.0},
+	"presence_penalty":  {0.0, 2.0},
+}
+
+// isParameter reports whether c was declared with PARAMETER, or a
+// ValueParameter extra command, rather than being one of the directives of
+// directiveSpecs or an extra command registered with
+// ParseOptions.ExtraCommands.
+func (c Command) isParameter() bool {
+	return c.spec == nil && !isDirective(c.Name)
+}
+
+// CollectParameters returns the typed values of the parameters in cmds. Stop
+// sequences and other list parameters accumulate while for every other
+// parameter the last value wins.
+func CollectParameters(cmds []Command) (map[string]any, error) {
+	params := make(map[string]any)
+	for _, cmd := range cmds {
+		if !cmd.isParameter() {
+			continue
+		}
+
+		if parameterKinds[cmd.Name] == reflect.Slice {
+			values, _ := params[cmd.Name].([]string)
+			params[cmd.Name] = append(values, cmd.Args)
+			continue
+		}
+
+		v, err := ParseParameter(cmd.Name, cmd.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		params[cmd.Name] = v
+	}
+
+	return params, nil
+}
+
+// NonDefaultParameters returns the typed values of the parameters in cmds
+// which differ from the defaults of api.DefaultOptions. List parameters, such
+// as stop, have no default and are always returned. Values which fail to
+// parse are skipped.
+func NonDefaultParameters(cmds []Command) map[string]any {
+	params := make(map[string]any)
+	for _, cmd := range cmds {
+		if !cmd.isParameter() {
+			continue
+		}
+
+		if parameterKinds[cmd.Name] == reflect.Slice {
+			values, _ := params[cmd.Name].([]string)
+			params[cmd.Name] = append(values, cmd.Args)
+			continue
+		}
+
+		v, err := ParseParameter(cmd.Name, cmd.Args)
+		if err != nil {
+			continue
+		}
+
+		params[cmd.Name] = v
+	}
+
+	for name, v := range params {
+		if def, ok := parameterDefaults[name]; ok && def == v {
+			delete(params, name)
+		}
+	}
+
+	return params
+}
+
+// parseBool parses a boolean parameter value. In addition to the values
+// accepted by strconv.ParseBool it accepts the common yes/no and on/off
+// synonyms, case-insensitively.
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "t", "1", "yes", "y", "on":
+		return true, true
+	case "false", "f", "0", "no", "n", "off":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// formatParameter returns the canonical textual form of a parameter value.
+// Boolean parameters are always written as true or false regardless of which
+// synonym was used in the source; all other values are returned unchanged.
+func formatParameter(name, value string) string {
+	if parameterKinds[name] == reflect.Bool {
+		if b, ok := parseBool(value); ok {
+			return strconv.FormatBool(b)
+		}
+	}
+
+	return value
+}
+
+// ParseParameter converts the value of the parameter name to its typed form:
+// float32 for float parameters, int64 for integer parameters, bool for boolean
+// parameters and string for everything else, including unknown parameters.
+func ParseParameter(name, value string) (any, error) {
+	value, err := stripDigitSeparators(name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch parameterKinds[name] {
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			if fixed := strings.Replace(value, ",", ".", 1); fixed != value {
+				if _, err := strconv.ParseFloat(fixed, 32); err == nil {
+					return nil, fmt.Errorf("use a period as the decimal separator: %s", fixed)
+				}
+			}
+
+			return nil, fmt.Errorf("invalid float value %q for %s", value, name)
+		}
+
+		return float32(f), nil
+	case reflect.Int:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int value %q for %s", value, name)
+		}
+
+		return i, nil
+	case reflect.Bool:
+		b, ok := parseBool(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool value %q for %s", value, name)
+		}
+
+		return b, nil
+	default:
+		return value, nil
+	}
+}
+
+// validateParameter checks that value has the type of the known parameter
+// name. Unknown parameters and list parameters accept any value.
+func validateParameter(name, value string) error {
+	var expects string
+	switch parameterKinds[name] {
+	case reflect.Float32, reflect.Float64:
+		expects = "a number"
+	case reflect.Int:
+		expects = "an integer"
+	case reflect.Bool:
+		expects = "a boolean"
+	default:
+		return nil
+	}
+
+	if _, err := ParseParameter(name, value); err != nil {
+		return fmt.Errorf("parameter %s expects %s: %w", name, expects, err)
+	}
+
+	return nil
+}
+
+// normalizeParameter returns the canonical form of the value of a typed
+// parameter, as api.FormatParams accepts it: surrounding whitespace and digit
+// separators are removed and booleans are written as true or false. Values of
+// other parameters are returned unchanged.
+func normalizeParameter(name, value string) (string, error) {
+	switch parameterKinds[name] {
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Bool:
+	default:
+		return value, nil
+	}
+
+	value, err := stripDigitSeparators(name, strings.TrimSpace(value))
+	if err != nil {
+		return "", err
+	}
+
+	return formatParameter(name, value), nil
+}
+
+var errInvalidDigitSeparator = errors.New("underscores must separate digits")
+
+// stripDigitSeparators removes the underscores grouping the digits of the
+// value of a numeric parameter, e.g. 131_072. Each underscore must be between
+// two digits. Values of other parameters are returned unchanged.
+func stripDigitSeparators(name, value string) (string, error) {
+	switch parameterKinds[name] {
+	case reflect.Float32, reflect.Float64, reflect.Int:
+	default:
+		return value, nil
+	}
+
+	if !strings.Contains(value, "_") {
+		return value, nil
+	}
+
+	for i := range len(value) {
+		if value[i] == '_' && (i == 0 || i == len(value)-1 || !isNumber(rune(value[i-1])) || !isNumber(rune(value[i+1]))) {
+			return "", fmt.Errorf("%w: %q for %s", errInvalidDigitSeparator, value, name)
+		}
+	}
+
+	return strings.ReplaceAll(value, "_", ""), nil
+}
@@ -0,0 +1,205 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseParameter(t *testing.T) {
+	var cases = []struct {
+		name, value string
+		expected    any
+		err         string
+	}{
+		{"temperature", "0.7", float32(0.7), ""},
+		{"temperature", "0,7", nil, "use a period as the decimal separator: 0.7"},
+		{"temperature", "warm", nil, `invalid float value "warm" for temperature`},
+		{"num_ctx", "2048", int64(2048), ""},
+		{"num_ctx", "2k", nil, `invalid int value "2k" for num_ctx`},
+		{"seed", "-1", int64(-1), ""},
+		{"seed", "9223372036854775807", int64(9223372036854775807), ""},
+		{"seed", "1.5", nil, `invalid int value "1.5" for seed`},
+		{"seed", "9223372036854775808", nil, `invalid int value "9223372036854775808" for seed`},
+		{"use_mmap", "yes", true, ""},
+		{"use_mmap", "maybe", nil, `invalid bool value "maybe" for use_mmap`},
+		{"num_ctx", "131_072", int64(131072), ""},
+		{"num_ctx", "_131072", nil, `underscores must separate digits: "_131072" for num_ctx`},
+		{"num_ctx", "131__072", nil, `underscores must separate digits: "131__072" for num_ctx`},
+		{"num_ctx", "131072_", nil, `underscores must separate digits: "131072_" for num_ctx`},
+		{"temperature", "1_000.5", float32(1000.5), ""},
+		{"stop", "</s>", "</s>", ""},
+		{"stop", "_a_", "_a_", ""},
+		{"unknown", "0,7", "0,7", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name+" "+c.value, func(t *testing.T) {
+			v, err := ParseParameter(c.name, c.value)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, v)
+		})
+	}
+}
+
+func TestCollectParameters(t *testing.T) {
+	params, err := CollectParameters([]Command{
+		{Name: "model", Args: "foo"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "stop", Args: "<|a|>"},
+		{Name: "system", Args: "You are a file parser."},
+		{Name: "temperature", Args: "0.9"},
+		{Name: "stop", Args: "<|b|>"},
+		{Name: "use_mmap", Args: "off"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"temperature": float32(0.9),
+		"stop":        []string{"<|a|>", "<|b|>"},
+		"use_mmap":    false,
+	}, params)
+
+	_, err = CollectParameters([]Command{{Name: "num_ctx", Args: "lots"}})
+	assert.Error(t, err)
+}
+
+func TestNonDefaultParameters(t *testing.T) {
+	params := NonDefaultParameters([]Command{
+		{Name: "model", Args: "foo"},
+		{Name: "temperature", Args: "0.8"},
+		{Name: "top_k", Args: "20"},
+		{Name: "num_ctx", Args: "2_048"},
+		{Name: "penalize_newline", Args: "yes"},
+		{Name: "use_mmap", Args: "off"},
+		{Name: "stop", Args: "<|eot_id|>"},
+		{Name: "num_thread", Args: "lots"},
+	})
+	assert.Equal(t, map[string]any{
+		"top_k":    int64(20),
+		"use_mmap": false,
+		"stop":     []string{"<|eot_id|>"},
+	}, params)
+}
+
+func TestParseFileParameterTypes(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []Command
+		err      string
+	}{
+		{
+			"PARAMETER num_ctx 4096",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "num_ctx", Args: "4096"}},
+			"",
+		},
+		{
+			"PARAMETER num_ctx abc",
+			nil,
+			`line 2, column 1: parameter num_ctx expects an integer: invalid int value "abc" for num_ctx`,
+		},
+		{
+			"PARAMETER use_mmap FALSE",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "use_mmap", Args: "false"}},
+			"",
+		},
+		{
+			"PARAMETER use_mmap yes",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "use_mmap", Args: "true"}},
+			"",
+		},
+		{
+			"PARAMETER use_mmap On",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "use_mmap", Args: "true"}},
+			"",
+		},
+		{
+			"PARAMETER use_mmap tRuE",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "use_mmap", Args: "true"}},
+			"",
+		},
+		{
+			"PARAMETER temperature   0.7",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "temperature", Args: "0.7"}},
+			"",
+		},
+		{
+			"PARAMETER temperature warm",
+			nil,
+			`line 2, column 1: parameter temperature expects a number: invalid float value "warm" for temperature`,
+		},
+		{
+			"PARAMETER temperature 0,7",
+			nil,
+			`line 2, column 1: parameter temperature expects a number: use a period as the decimal separator: 0.7`,
+		},
+		{
+			"PARAMETER not_a_parameter abc",
+			[]Command{{Name: "model", Args: "foo"}, {Name: "not_a_parameter", Args: "abc"}},
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader("FROM foo\n" + c.input))
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, modelfile.Commands)
+		})
+	}
+
+	assert.ErrorIs(t, validateParameter("num_ctx", "4__096"), errInvalidDigitSeparator)
+}
+
+func TestParseFileDigitSeparators(t *testing.T) {
+	modelfile, err := ParseFile(strings.NewReader("FROM foo\nPARAMETER num_ctx 131_072\nPARAMETER num_predict 128\nPARAMETER stop <_s_>"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "foo"},
+		{Name: "num_ctx", Args: "131072"},
+		{Name: "num_predict", Args: "128"},
+		{Name: "stop", Args: "<_s_>"},
+	}, modelfile.Commands)
+
+	_, err = ParseFile(strings.NewReader("FROM foo\nPARAMETER num_ctx _131072"))
+	assert.ErrorIs(t, err, errInvalidDigitSeparator)
+}
+
+func TestParseFileParameterValidator(t *testing.T) {
+	input := "FROM foo\nPARAMETER temperature 0.7\n\nPARAMETER num_gqa 8\n"
+
+	var seen []string
+	modelfile, err := ParseFileWithOptions(strings.NewReader(input), ParseOptions{
+		ParameterValidator: func(name, value string) error {
+			seen = append(seen, name+"="+value)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"temperature=0.7", "num_gqa=8"}, seen)
+	assert.Len(t, modelfile.Commands, 3)
+
+	errDeprecated := errors.New("num_gqa is deprecated")
+	_, err = ParseFileWithOptions(strings.NewReader(input), ParseOptions{
+		ParameterValidator: func(name, value string) error {
+			if name == "num_gqa" {
+				return errDeprecated
+			}
+
+			return nil
+		},
+	})
+	assert.ErrorIs(t, err, errDeprecated)
+	assert.EqualError(t, err, "line 4, column 1: PARAMETER num_gqa: num_gqa is deprecated")
+}
@@ -0,0 +1,20 @@
+package model
+
+import "errors"
+
+var errPrefillConflict = errors.New("PREFILL cannot be combined with a final assistant MESSAGE")
+
+// Prefill returns the PREFILL declared in cmds: text the assistant's response
+// is seeded with, e.g. the opening of a JSON object. It is typically written
+// as a """ block. A Modelfile may not both set PREFILL and end its seeded
+// conversation with an assistant MESSAGE, as each would begin the response.
+func Prefill(cmds []Command) (string, bool) {
+	value, ok := "", false
+	for _, cmd := range cmds {
+		if cmd.Name == "prefill" {
+			value, ok = cmd.Args, true
+		}
+	}
+
+	return value, ok
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefill(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected string
+		ok       bool
+		err      error
+	}{
+		{
+			"FROM llama3\nPREFILL \"\"\"{\n  \"answer\": \"\"\"",
+			"{\n  \"answer\": ",
+			true,
+			nil,
+		},
+		{
+			"FROM llama3\nprefill Sure,",
+			"Sure,",
+			true,
+			nil,
+		},
+		{
+			"FROM llama3\nPREFILL Sure,\nMESSAGE user hi\nMESSAGE assistant hello\nMESSAGE user bye",
+			"Sure,",
+			true,
+			nil,
+		},
+		{
+			"FROM llama3\nMESSAGE user hi\nMESSAGE assistant hello",
+			"",
+			false,
+			nil,
+		},
+		{
+			"FROM llama3\nPREFILL Sure,\nMESSAGE user hi\nMESSAGE assistant hello",
+			"",
+			false,
+			errPrefillConflict,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c.input))
+			assert.ErrorIs(t, err, c.err)
+			if err != nil {
+				return
+			}
+
+			prefill, ok := Prefill(modelfile.Commands)
+			assert.Equal(t, c.expected, prefill)
+			assert.Equal(t, c.ok, ok)
+		})
+	}
+
+	cmds := []Command{{Name: "model", Args: "llama3"}, {Name: "prefill", Args: "{\n  \"answer\": "}}
+	modelfile, err := ParseFile(strings.NewReader(File{Commands: cmds}.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, cmds, modelfile.Commands)
+}
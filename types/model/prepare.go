@@ -0,0 +1,108 @@
+package model
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"slices"
+)
+
+// PrepareOptions configures Prepare.
+type PrepareOptions struct {
+	// Name is the name of the model being created. When set, a FROM which
+	// refers to the model itself is reported.
+	Name string
+
+	// Parse configures parsing. Its BaseDir is also the directory local
+	// files referenced by FROM and ADAPTER are resolved against.
+	Parse ParseOptions
+
+	// Lint configures the diagnostics reported in PreparedModel.Diagnostics.
+	Lint ValidateOptions
+}
+
+// PreparedModel is a Modelfile which has been parsed, validated and resolved
+// and is ready to be created.
+type PreparedModel struct {
+	// Commands are the commands of the Modelfile with INCLUDE, INHERIT and
+	// PARAMETER preset expanded.
+	Commands []Command
+
+	// Manifest holds the local files the Modelfile references.
+	Manifest *Manifest
+
+	// Parameters holds the typed value of every parameter: those set in the
+	// Modelfile outside of any VARIANT and the defaults of
+	// api.DefaultOptions for the rest.
+	Parameters map[string]any
+
+	Adapters []Adapter
+	Merges   []MergeComponent
+
+	// Diagnostics and Warnings are advisory and do not prevent creation.
+	Diagnostics []Diagnostic
+	Warnings    []Warning
+}
+
+// Prepare parses the Modelfile read from r and readies it for creation. It
+// checks the rules of Validate, that FROM does not refer to opts.Name and
+// that every local file exists, reporting every problem found, joined with
+// errors.Join, rather than stopping at the first. A Modelfile which cannot be
+// parsed is reported on its own as nothing further can be checked.
+func Prepare(r io.Reader, opts PrepareOptions) (*PreparedModel, error) {
+	parseOpts := opts.Parse
+	parseOpts.warnings = true
+
+	f, err := ParseFileWithOptions(r, parseOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := PreparedModel{
+		Commands:    f.Commands,
+		Diagnostics: append(f.Diagnostics, Lint(f.Commands, opts.Lint)...),
+		Warnings:    f.warnings,
+	}
+
+	var errs []error
+	if err := Validate(f.Commands); err != nil {
+		errs = append(errs, err)
+	}
+
+	if opts.Name != "" {
+		if err := DetectSelfReference(f.Commands, opts.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if m.Manifest, err = ToBuildManifest(f.Commands, opts.Parse.BaseDir); err != nil {
+		errs = append(errs, err)
+	}
+
+	if m.Adapters, err = Adapters(f.Commands); err != nil {
+		errs = append(errs, err)
+	}
+
+	if m.Merges, err = Merges(f.Commands); err != nil {
+		errs = append(errs, err)
+	}
+
+	shared := f.Commands
+	if i := slices.IndexFunc(shared, func(cmd Command) bool { return cmd.Name == "variant" }); i >= 0 {
+		shared = shared[:i]
+	}
+
+	params, err := CollectParameters(shared)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	m.Parameters = maps.Clone(parameterDefaults)
+	maps.Copy(m.Parameters, params)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
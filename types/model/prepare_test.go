@@ -0,0 +1,55 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepare(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lora.gguf"), []byte("lora"), 0o644))
+
+	m, err := Prepare(strings.NewReader(`FROM llama3
+ADAPTER name=style ./lora.gguf 0.5
+PARAMETER preset precise
+PARAMETER num_ctx 8192
+PARAMETER stop <|eot_id|>
+PARAMETER bogus 1
+MESSAGE user Hi
+MESSAGE assistant Hello!
+`), PrepareOptions{Name: "mymodel", Parse: ParseOptions{BaseDir: dir}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Adapter{{Name: "style", Path: "./lora.gguf", Scale: 0.5}}, m.Adapters)
+	assert.Equal(t, []ManifestFile{{Command: "adapter", Ref: "./lora.gguf", Path: filepath.Join(dir, "lora.gguf"), Size: 4}}, m.Manifest.Files)
+	assert.Equal(t, int64(8192), m.Parameters["num_ctx"])
+	assert.Equal(t, float32(0.2), m.Parameters["temperature"])
+	assert.Equal(t, []string{"<|eot_id|>"}, m.Parameters["stop"])
+	assert.Equal(t, parameterDefaults["repeat_penalty"], m.Parameters["repeat_penalty"])
+	assert.Equal(t, []Warning{{Message: `unknown parameter "bogus"`, Line: 6}}, m.Warnings)
+	assert.Empty(t, m.Merges)
+
+	// every problem is reported together
+	_, err = Prepare(strings.NewReader(`FROM mymodel
+ADAPTER ./missing.gguf
+PARAMETER temperature 0.5
+PARAMETER temperature 0.7
+MESSAGE assistant Hello!
+`), PrepareOptions{Name: "mymodel", Parse: ParseOptions{BaseDir: dir}})
+	assert.ErrorIs(t, err, errConflictingParameter)
+	assert.ErrorIs(t, err, errAssistantBeforeUser)
+	assert.ErrorIs(t, err, errSelfReference)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// mutually exclusive conditional FROMs stand in for a single FROM
+	_, err = Prepare(strings.NewReader("FROM llama3:q4_0 [mem<8G]\nFROM llama3:q8_0 [mem>=8G]\n"), PrepareOptions{})
+	assert.NoError(t, err)
+
+	// parse errors are reported on their own
+	_, err = Prepare(strings.NewReader("FROM llama3\nBOGUS value\n"), PrepareOptions{})
+	assert.ErrorIs(t, err, errInvalidCommand)
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var errUnknownPreset = errors.New("unknown preset")
+
+// DefaultPresets are the named parameter sets which PARAMETER preset <name>
+// expands into. Callers may override them with ParseOptions.Presets.
+var DefaultPresets = map[string]map[string]string{
+	"creative": {
+		"temperature": "1.1",
+		"top_p":       "0.95",
+		"top_k":       "80",
+	},
+	"precise": {
+		"temperature": "0.2",
+		"top_p":       "0.5",
+		"top_k":       "20",
+	},
+	"deterministic": {
+		"temperature": "0",
+		"top_k":       "1",
+		"seed":        "42",
+	},
+}
+
+// expandPresets replaces every PARAMETER preset command with the parameters
+// of the named preset, in name order, skipping parameters which are set
+// explicitly elsewhere in cmds.
+func expandPresets(cmds []Command, presets map[string]map[string]string) ([]Command, error) {
+	if !slices.ContainsFunc(cmds, func(cmd Command) bool { return cmd.Name == "preset" }) {
+		return cmds, nil
+	}
+
+	if presets == nil {
+		presets = DefaultPresets
+	}
+
+	explicit := make(map[string]bool)
+	for _, cmd := range cmds {
+		explicit[cmd.Name] = true
+	}
+
+	expanded := make([]Command, 0, len(cmds))
+	for _, cmd := range cmds {
+		if cmd.Name != "preset" {
+			expanded = append(expanded, cmd)
+			continue
+		}
+
+		preset, ok := presets[cmd.Args]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errUnknownPreset, cmd.Args)
+		}
+
+		names := make([]string, 0, len(preset))
+		for name := range preset {
+			if !explicit[name] {
+				names = append(names, name)
+			}
+		}
+
+		slices.Sort(names)
+		for _, name := range names {
+			expanded = append(expanded, Command{Name: name, Args: preset[name], Constraints: cmd.Constraints, line: cmd.line})
+		}
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilePresets(t *testing.T) {
+	var cases = []struct {
+		input    string
+		opts     ParseOptions
+		expected []Command
+		err      error
+	}{
+		{
+			"FROM foo\nPARAMETER preset creative",
+			ParseOptions{},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "temperature", Args: "1.1"},
+				{Name: "top_k", Args: "80"},
+				{Name: "top_p", Args: "0.95"},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER preset precise",
+			ParseOptions{},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "temperature", Args: "0.2"},
+				{Name: "top_k", Args: "20"},
+				{Name: "top_p", Args: "0.5"},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER preset deterministic",
+			ParseOptions{},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "seed", Args: "42"},
+				{Name: "temperature", Args: "0"},
+				{Name: "top_k", Args: "1"},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER temperature 0.5\nPARAMETER preset creative",
+			ParseOptions{},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "temperature", Args: "0.5"},
+				{Name: "top_k", Args: "80"},
+				{Name: "top_p", Args: "0.95"},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER preset creative",
+			ParseOptions{Presets: map[string]map[string]string{"creative": {"temperature": "1.5"}}},
+			[]Command{
+				{Name: "model", Args: "foo"},
+				{Name: "temperature", Args: "1.5"},
+			},
+			nil,
+		},
+		{
+			"FROM foo\nPARAMETER preset wild",
+			ParseOptions{},
+			nil,
+			errUnknownPreset,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), c.opts)
+			assert.ErrorIs(t, err, c.err)
+			if modelfile != nil {
+				assert.Equal(t, c.expected, modelfile.Commands)
+			}
+		})
+	}
+}
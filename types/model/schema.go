@@ -0,0 +1,192 @@
+package model
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DirectiveSpec describes a Modelfile directive, e.g. FROM, or a PARAMETER,
+// e.g. temperature, for editor completion and documentation.
+type DirectiveSpec struct {
+	// Name is the directive as written, e.g. FROM, or the parameter name.
+	Name string
+	// Kind is "directive" or "parameter".
+	Kind string
+	// Type is the type of the value: "string", "int", "float", "bool" or, for
+	// MESSAGE, "message", a role followed by content.
+	Type string
+	// Repeatable reports whether the directive or parameter may be given
+	// more than once, each occurrence adding a value.
+	Repeatable bool
+	// Default is the value a parameter takes when it is not set, formatted
+	// as it would be written. It is empty for directives.
+	Default     string
+	Description string
+	// Constraints restricts the values accepted, e.g. the roles of MESSAGE,
+	// or for some parameters gives their typical range.
+	Constraints []string
+}
+
+// messageRoles are the roles a MESSAGE may be sent as.
+var messageRoles = []string{"system", "user", "assistant", "tool"}
+
+// directiveSpecs are the directives accepted by the parser, in the order they
+// are documented.
+var directiveSpecs = []DirectiveSpec{
+	{Name: "FROM", Description: "Defines the base model to use."},
+	{Name: "PARAMETER", Repeatable: true, Description: "Sets the parameters for how the model is run."},
+	{Name: "TEMPLATE", Description: "The full prompt template to be sent to the model."},
+	{Name: "SYSTEM", Description: "Specifies the system message that will be set in the template."},
+	{Name: "ADAPTER", Repeatable: true, Description: "Defines the (Q)LoRA adapters to apply to the model."},
+	{Name: "LICENSE", Repeatable: true, Description: "Specifies the legal license."},
+	{Name: "MESSAGE", Type: "message", Repeatable: true, Description: "Specifies message history.", Constraints: messageRoles},
+	{Name: "DESCRIPTION", Description: "A description of the model shown in listings."},
+	{Name: "PREFILL", Description: "Seeds the start of the assistant's response."},
+	{Name: "TOKENIZER", Description: "Overrides the tokenizer, inline or with @path."},
+	{Name: "MERGE", Description: "Merges the weights of models, written model:weight."},
+	{Name: "INCLUDE", Repeatable: true, Description: "Includes the commands of another Modelfile."},
+	{Name: "INHERIT", Description: "Inherits the commands of a stored Modelfile, which this file overrides."},
+	{Name: "VARIANT", Repeatable: true, Description: "Starts a named set of commands overriding those before the first VARIANT."},
+}
+
+// directiveCommand returns the name of the commands produced by the
+// directive keyword, e.g. model for FROM. It reports false for PARAMETER,
+// whose commands are named after their parameter.
+func directiveCommand(keyword string) (string, bool) {
+	switch keyword {
+	case "FROM":
+		return "model", true
+	case "PARAMETER":
+		return "", false
+	default:
+		return strings.ToLower(keyword), true
+	}
+}
+
+// isDirective reports whether name is the name of the commands produced by a
+// directive of directiveSpecs other than PARAMETER, or of those recording
+// annotations and comments.
+func isDirective(name string) bool {
+	if name == "annotation" || name == "comment" {
+		return true
+	}
+
+	return slices.ContainsFunc(directiveSpecs, func(spec DirectiveSpec) bool {
+		command, ok := directiveCommand(spec.Name)
+		return ok && command == name
+	})
+}
+
+// directiveList lists the directives of directiveSpecs for error messages,
+// e.g. "from", "parameter", or "variant".
+func directiveList() string {
+	var sb strings.Builder
+	for i, spec := range directiveSpecs {
+		switch i {
+		case 0:
+		case len(directiveSpecs) - 1:
+			sb.WriteString(", or ")
+		default:
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(strconv.Quote(strings.ToLower(spec.Name)))
+	}
+
+	return sb.String()
+}
+
+// parameterDescriptions describes the documented parameters.
+var parameterDescriptions = map[string]string{
+	"mirostat":       "Enables Mirostat sampling for controlling perplexity (0 = disabled, 1 = Mirostat, 2 = Mirostat 2.0).",
+	"mirostat_eta":   "Influences how quickly Mirostat responds to feedback from the generated text.",
+	"mirostat_tau":   "Controls the balance between coherence and diversity of the output.",
+	"num_ctx":        "Sets the size of the context window used to generate the next token.",
+	"repeat_last_n":  "Sets how far back the model looks to prevent repetition (0 = disabled, -1 = num_ctx).",
+	"repeat_penalty": "Sets how strongly to penalize repetitions.",
+	"temperature":    "The temperature of the model. Higher values make answers more creative.",
+	"seed":           "Sets the random number seed to use for generation.",
+	"stop":           "Sets a stop sequence. Generation stops when it is encountered.",
+	"tfs_z":          "Tail free sampling reduces the impact of less probable tokens (1 = disabled).",
+	"num_predict":    "Maximum number of tokens to predict (-1 = infinite, -2 = fill context).",
+	"top_k":          "Reduces the probability of generating nonsense. Higher values give more diverse answers.",
+	"top_p":          "Works together with top_k. Higher values give more diverse text.",
+}
+
+// Schema returns the specification of every directive followed by every
+// parameter, sorted by name. It is derived from the tables the parser uses so
+// that the two stay in sync.
+func Schema() []DirectiveSpec {
+	specs := make([]DirectiveSpec, 0, len(directiveSpecs)+len(parameterKinds)+1)
+	for _, spec := range directiveSpecs {
+		spec.Kind = "directive"
+		if spec.Type == "" {
+			spec.Type = "string"
+		}
+
+		spec.Constraints = slices.Clone(spec.Constraints)
+		specs = append(specs, spec)
+	}
+
+	var params []DirectiveSpec
+	for name, kind := range parameterKinds {
+		spec := DirectiveSpec{Name: name, Kind: "parameter", Description: parameterDescriptions[name]}
+		switch kind {
+		case reflect.Float32, reflect.Float64:
+			spec.Type = "float"
+		case reflect.Int:
+			spec.Type = "int"
+		case reflect.Bool:
+			spec.Type = "bool"
+		case reflect.Slice:
+			spec.Type, spec.Repeatable = "string", true
+		default:
+			spec.Type = "string"
+		}
+
+		if v, ok := parameterDefaults[name]; ok {
+			spec.Default = formatDefault(v)
+		}
+
+		if r, ok := parameterRanges[name]; ok {
+			spec.Constraints = []string{fmt.Sprintf("typical range %.1f-%.1f", r.min, r.max)}
+		}
+
+		params = append(params, spec)
+	}
+
+	var presets []string
+	for name := range DefaultPresets {
+		presets = append(presets, name)
+	}
+
+	slices.Sort(presets)
+
+	params = append(params, DirectiveSpec{
+		Name:        "preset",
+		Kind:        "parameter",
+		Type:        "string",
+		Repeatable:  true,
+		Description: "Expands into the parameters of a named preset.",
+		Constraints: presets,
+	})
+
+	slices.SortFunc(params, func(a, b DirectiveSpec) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return append(specs, params...)
+}
+
+func formatDefault(v any) string {
+	switch v := v.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+
+	specs := make(map[string]DirectiveSpec)
+	for _, spec := range schema {
+		specs[spec.Kind+" "+spec.Name] = spec
+	}
+
+	assert.Len(t, specs, len(schema), "names are unique")
+
+	for _, name := range []string{"FROM", "PARAMETER", "TEMPLATE", "SYSTEM", "ADAPTER", "LICENSE", "MESSAGE", "DESCRIPTION", "PREFILL", "TOKENIZER", "MERGE", "INCLUDE", "INHERIT", "VARIANT"} {
+		spec, ok := specs["directive "+name]
+		assert.True(t, ok, name)
+		assert.NotEmpty(t, spec.Description, name)
+	}
+
+	for _, spec := range schema {
+		switch spec.Kind {
+		case "directive":
+			assert.True(t, isValidCommand(strings.ToLower(spec.Name)), spec.Name)
+		case "parameter":
+			_, ok := parameterKinds[spec.Name]
+			assert.True(t, ok || spec.Name == "preset", spec.Name)
+		default:
+			t.Errorf("unexpected kind %q", spec.Kind)
+		}
+	}
+
+	for name := range parameterKinds {
+		_, ok := specs["parameter "+name]
+		assert.True(t, ok, name)
+	}
+
+	assert.Equal(t, DirectiveSpec{
+		Name:        "temperature",
+		Kind:        "parameter",
+		Type:        "float",
+		Default:     "0.8",
+		Description: "The temperature of the model. Higher values make answers more creative.",
+	}, specs["parameter temperature"])
+	assert.Equal(t, "string", specs["parameter stop"].Type)
+	assert.True(t, specs["parameter stop"].Repeatable)
+	assert.Equal(t, []string{"typical range 1.0-1.3"}, specs["parameter repeat_penalty"].Constraints)
+	assert.Equal(t, messageRoles, specs["directive MESSAGE"].Constraints)
+	assert.Equal(t, "message", specs["directive MESSAGE"].Type)
+}
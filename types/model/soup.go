@@ -0,0 +1,68 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var errInvalidMerge = errors.New("invalid merge component")
+
+// MergeComponent is a model in a weighted merge declared with MERGE, e.g.
+// MERGE modelA:0.5 modelB:0.5.
+type MergeComponent struct {
+	Ref    string
+	Weight float64
+}
+
+// Merges returns the components of every MERGE command in cmds.
+func Merges(cmds []Command) ([]MergeComponent, error) {
+	var components []MergeComponent
+	for _, cmd := range cmds {
+		if cmd.Name != "merge" {
+			continue
+		}
+
+		c, err := parseMerge(cmd.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		components = append(components, c...)
+	}
+
+	return components, nil
+}
+
+// parseMerge parses the space separated ref:weight components of a MERGE
+// command. The weight follows the last colon so refs may include a tag, e.g.
+// llama3:8b:0.5.
+func parseMerge(s string) ([]MergeComponent, error) {
+	var components []MergeComponent
+	for _, field := range strings.Fields(s) {
+		i := strings.LastIndex(field, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q: expected ref:weight", errInvalidMerge, field)
+		}
+
+		ref := field[:i]
+		weight, err := strconv.ParseFloat(field[i+1:], 64)
+		if err != nil || math.IsNaN(weight) || weight <= 0 || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("%w: %q: invalid weight", errInvalidMerge, field)
+		}
+
+		if !isPathLike(ref) && !strings.HasPrefix(ref, "@") && !ParseName(ref).IsValid() {
+			return nil, fmt.Errorf("%w: %q: invalid model reference", errInvalidMerge, field)
+		}
+
+		components = append(components, MergeComponent{Ref: ref, Weight: weight})
+	}
+
+	if len(components) < 2 {
+		return nil, fmt.Errorf("%w: MERGE requires at least two models", errInvalidMerge)
+	}
+
+	return components, nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerges(t *testing.T) {
+	modelfile, err := ParseFile(strings.NewReader("FROM llama3\nMERGE llama3:8b:0.5 mistral:0.5"))
+	assert.NoError(t, err)
+
+	components, err := Merges(modelfile.Commands)
+	assert.NoError(t, err)
+	assert.Equal(t, []MergeComponent{{Ref: "llama3:8b", Weight: 0.5}, {Ref: "mistral", Weight: 0.5}}, components)
+	assert.Empty(t, Lint(modelfile.Commands, ValidateOptions{}))
+	assert.Equal(t, "FROM llama3\nMERGE llama3:8b:0.5 mistral:0.5\n", modelfile.String())
+
+	modelfile, err = ParseFile(strings.NewReader("FROM llama3\nMERGE llama3:0.7 mistral:0.7"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Diagnostic{
+		{Severity: SeverityWarning, Message: "MERGE weights sum to 1.4, not 1"},
+	}, Lint(modelfile.Commands, ValidateOptions{}))
+
+	var cases = []string{
+		"FROM llama3\nMERGE bad/ref/with/too/many/parts!:0.5 mistral:0.5",
+		"FROM llama3\nMERGE llama3:heavy mistral:0.5",
+		"FROM llama3\nMERGE llama3 mistral:0.5",
+		"FROM llama3\nMERGE llama3:1",
+		"FROM llama3\nMERGE llama3:NaN mistral:0.5",
+		"FROM llama3\nMERGE llama3:+Inf mistral:0.5",
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseFile(strings.NewReader(c))
+			assert.ErrorIs(t, err, errInvalidMerge)
+		})
+	}
+}
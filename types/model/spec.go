@@ -0,0 +1,101 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var errInvalidSpec = errors.New("invalid model spec")
+
+// ParseSpec parses a compact, single line model definition such as
+//
+//	llama3; temperature=0.7; top_p=0.9; system="You are helpful"
+//
+// The first element is the model to build from and the remaining elements
+// are key=value pairs naming a parameter or one of template, system, license,
+// adapter or tokenizer. Values containing semicolons or surrounding spaces
+// must be double quoted using Go string syntax.
+func ParseSpec(s string) ([]Command, error) {
+	fields, err := splitSpec(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 || fields[0] == "" || strings.Contains(fields[0], "=") {
+		return nil, fmt.Errorf("%w: must start with a model", errInvalidSpec)
+	}
+
+	cmds := []Command{{Name: "model", Args: fields[0]}}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: expected key=value: %q", errInvalidSpec, field)
+		}
+
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, fmt.Errorf("%w: invalid quoted value for %s", errInvalidSpec, key)
+			}
+		}
+
+		// values are normalized and validated as ParseFile does
+		switch key {
+		case "template", "system", "license", "tokenizer":
+		case "adapter":
+			if _, err := validateAdapter(value, 0); err != nil {
+				return nil, fmt.Errorf("%w: %w", errInvalidSpec, err)
+			}
+		default:
+			if _, ok := parameterKinds[key]; !ok {
+				return nil, fmt.Errorf("%w: unknown key %q", errInvalidSpec, key)
+			}
+
+			if value, err = normalizeParameter(key, value); err != nil {
+				return nil, fmt.Errorf("%w: %w", errInvalidSpec, err)
+			}
+
+			if err := validateParameter(key, value); err != nil {
+				return nil, fmt.Errorf("%w: %w", errInvalidSpec, err)
+			}
+		}
+
+		cmds = append(cmds, Command{Name: key, Args: value})
+	}
+
+	return cmds, nil
+}
+
+// splitSpec splits s at semicolons which are not inside double quotes and
+// trims the resulting fields. A trailing semicolon is allowed.
+func splitSpec(s string) ([]string, error) {
+	var fields []string
+	var quoted, escaped bool
+	start := 0
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			fields = append(fields, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated quoted string", errInvalidSpec)
+	}
+
+	if last := strings.TrimSpace(s[start:]); last != "" || len(fields) == 0 {
+		fields = append(fields, last)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpec(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []Command
+		err      string
+	}{
+		{
+			`llama3; temperature=0.7; top_p=0.9; system="You are helpful"`,
+			[]Command{
+				{Name: "model", Args: "llama3"},
+				{Name: "temperature", Args: "0.7"},
+				{Name: "top_p", Args: "0.9"},
+				{Name: "system", Args: "You are helpful"},
+			},
+			"",
+		},
+		{
+			`llama3:8b;system="Answer; then stop.\nBe \"brief\"";stop=</s>;`,
+			[]Command{
+				{Name: "model", Args: "llama3:8b"},
+				{Name: "system", Args: "Answer; then stop.\nBe \"brief\""},
+				{Name: "stop", Args: "</s>"},
+			},
+			"",
+		},
+		{
+			// values are normalized as ParseFile does
+			`llama3; num_ctx=131_072; use_mmap=yes; temperature=" 0.7"`,
+			[]Command{
+				{Name: "model", Args: "llama3"},
+				{Name: "num_ctx", Args: "131072"},
+				{Name: "use_mmap", Args: "true"},
+				{Name: "temperature", Args: "0.7"},
+			},
+			"",
+		},
+		{"llama3", []Command{{Name: "model", Args: "llama3"}}, ""},
+		{"", nil, "invalid model spec: must start with a model"},
+		{"temperature=0.7; llama3", nil, "invalid model spec: must start with a model"},
+		{"llama3; temperature", nil, `invalid model spec: expected key=value: "temperature"`},
+		{"llama3; temperature=hot", nil, `invalid model spec: parameter temperature expects a number: invalid float value "hot" for temperature`},
+		{"llama3; num_ctx=4__096", nil, `invalid model spec: underscores must separate digits: "4__096" for num_ctx`},
+		{"llama3; adapter=lora.bin -20%", nil, "invalid model spec: adapter scale must not be negative: -20%"},
+		{"llama3; colour=blue", nil, `invalid model spec: unknown key "colour"`},
+		{`llama3; system="unterminated`, nil, "invalid model spec: unterminated quoted string"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			cmds, err := ParseSpec(c.input)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, cmds)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+)
+
+var errTarEntryNotFound = errors.New("entry not found in tar archive")
+
+// ParseTarLayer parses the Modelfile stored as the entry name of the tar
+// stream r, such as a layer of a pulled model, without extracting the
+// archive. Entry names are compared after cleaning so "./Modelfile" matches
+// "Modelfile".
+func ParseTarLayer(r io.Reader, name string) ([]Command, error) {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %s", errTarEntryNotFound, name)
+		} else if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg || path.Clean(hdr.Name) != path.Clean(name) {
+			continue
+		}
+
+		f, err := ParseFile(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		return f.Commands, nil
+	}
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTarLayer(t *testing.T) {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for name, content := range map[string]string{
+		"model.gguf":  "GGUF",
+		"./Modelfile": "FROM llama3\nPARAMETER temperature 0.7\n",
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmds, err := ParseTarLayer(bytes.NewReader(b.Bytes()), "Modelfile")
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "llama3"},
+		{Name: "temperature", Args: "0.7"},
+	}, cmds)
+
+	_, err = ParseTarLayer(bytes.NewReader(b.Bytes()), "missing/Modelfile")
+	assert.ErrorIs(t, err, errTarEntryNotFound)
+	assert.ErrorContains(t, err, "missing/Modelfile")
+}
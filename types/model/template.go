@@ -0,0 +1,201 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+var errTemplateRecursion = errors.New("template appears to recurse")
+
+// TemplateFields returns the sorted names of the top-level fields referenced
+// by a TEMPLATE, e.g. System and Prompt for "{{ .System }} {{ .Prompt }}".
+// Fields referenced relative to a range or with block, such as the Content in
+// "{{ range .Messages }}{{ .Content }}{{ end }}", are not top-level.
+func TemplateFields(args string) ([]string, error) {
+	tree, err := parseTemplate(args)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool)
+	walkTemplateFields(tree.Root, true, fields)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names, nil
+}
+
+// parseTemplate parses a TEMPLATE without requiring its functions to be
+// defined.
+func parseTemplate(s string) (*parse.Tree, error) {
+	tree := parse.New("")
+	tree.Mode = parse.SkipFuncCheck
+	return tree.Parse(s, "", "", map[string]*parse.Tree{})
+}
+
+func walkTemplateFields(node parse.Node, root bool, fields map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, n := range n.Nodes {
+			walkTemplateFields(n, root, fields)
+		}
+	case *parse.ActionNode:
+		walkTemplateFields(n.Pipe, root, fields)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+
+		for _, cmd := range n.Cmds {
+			walkTemplateFields(cmd, root, fields)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplateFields(arg, root, fields)
+		}
+	case *parse.ChainNode:
+		walkTemplateFields(n.Node, root, fields)
+	case *parse.FieldNode:
+		if root {
+			fields[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			fields[n.Ident[1]] = true
+		}
+	case *parse.IfNode:
+		walkTemplateFields(n.Pipe, root, fields)
+		walkTemplateFields(n.List, root, fields)
+		walkTemplateFields(n.ElseList, root, fields)
+	case *parse.RangeNode:
+		walkTemplateFields(n.Pipe, root, fields)
+		walkTemplateFields(n.List, false, fields)
+		walkTemplateFields(n.ElseList, root, fields)
+	case *parse.WithNode:
+		walkTemplateFields(n.Pipe, root, fields)
+		walkTemplateFields(n.List, false, fields)
+		walkTemplateFields(n.ElseList, root, fields)
+	case *parse.TemplateNode:
+		walkTemplateFields(n.Pipe, root, fields)
+	}
+}
+
+// ValidateTemplate parses a TEMPLATE and renders it with sample data, reporting
+// any error the server would encounter. Templates which invoke themselves,
+// directly or through other named templates, are rejected before rendering
+// since they would never terminate.
+func ValidateTemplate(s string) error {
+	tmpl, err := template.New("").Option("missingkey=zero").Parse(s)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTemplateRecursion(tmpl, tmpl.Tree.Root, nil); err != nil {
+		return err
+	}
+
+	return tmpl.Execute(io.Discard, map[string]any{
+		"System":   "system",
+		"Prompt":   "prompt",
+		"Response": "response",
+	})
+}
+
+// checkTemplateRecursion follows every template invocation reachable from
+// node and reports an error if one re-enters a template in stack.
+func checkTemplateRecursion(tmpl *template.Template, node parse.Node, stack []string) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+
+		for _, n := range n.Nodes {
+			if err := checkTemplateRecursion(tmpl, n, stack); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkTemplateBranch(tmpl, n.BranchNode, stack)
+	case *parse.RangeNode:
+		return checkTemplateBranch(tmpl, n.BranchNode, stack)
+	case *parse.WithNode:
+		return checkTemplateBranch(tmpl, n.BranchNode, stack)
+	case *parse.TemplateNode:
+		if slices.Contains(stack, n.Name) {
+			return fmt.Errorf("%w: %s", errTemplateRecursion, strings.Join(append(stack, n.Name), " -> "))
+		}
+
+		if t := tmpl.Lookup(n.Name); t != nil && t.Tree != nil {
+			return checkTemplateRecursion(tmpl, t.Tree.Root, append(slices.Clip(stack), n.Name))
+		}
+	}
+
+	return nil
+}
+
+func checkTemplateBranch(tmpl *template.Template, n parse.BranchNode, stack []string) error {
+	if err := checkTemplateRecursion(tmpl, n.List, stack); err != nil {
+		return err
+	}
+
+	return checkTemplateRecursion(tmpl, n.ElseList, stack)
+}
+
+// templateRequirementRe matches a requirement declared in a TEMPLATE comment,
+// e.g. {{/* requires num_ctx>=4096 */}}.
+var templateRequirementRe = regexp.MustCompile(`\{\{-?\s*/\*\s*requires\s+([a-z_]+)\s*(>=|<=|>|<|=)\s*([0-9.]+)\s*\*/\s*-?\}\}`)
+
+// templateRequirement is a minimum or maximum parameter value a TEMPLATE
+// expects.
+type templateRequirement struct {
+	Name  string
+	Op    string
+	Value float64
+}
+
+func (r templateRequirement) satisfiedBy(v float64) bool {
+	switch r.Op {
+	case ">=":
+		return v >= r.Value
+	case ">":
+		return v > r.Value
+	case "<=":
+		return v <= r.Value
+	case "<":
+		return v < r.Value
+	default:
+		return v == r.Value
+	}
+}
+
+// templateRequirements returns the parameter requirements declared in a
+// TEMPLATE.
+func templateRequirements(s string) []templateRequirement {
+	var reqs []templateRequirement
+	for _, m := range templateRequirementRe.FindAllStringSubmatch(s, -1) {
+		v, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			continue
+		}
+
+		reqs = append(reqs, templateRequirement{Name: m[1], Op: m[2], Value: v})
+	}
+
+	return reqs
+}
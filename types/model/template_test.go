@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateFields(t *testing.T) {
+	var cases = []struct {
+		template string
+		expected []string
+	}{
+		{"", []string{}},
+		{"{{ .Prompt }}", []string{"Prompt"}},
+		{"{{ if .System }}<|system|>{{ .System }}{{ end }}<|user|>{{ .Prompt }}<|assistant|>{{ .Response }}", []string{"Prompt", "Response", "System"}},
+		{"{{ range .Messages }}{{ .Role }}: {{ .Content }}{{ if $.Tools }}{{ json $.Tools }}{{ end }}{{ end }}", []string{"Messages", "Tools"}},
+		{"{{ with .System }}{{ . }}{{ else }}{{ .Prompt }}{{ end }}", []string{"Prompt", "System"}},
+		{"{{ range $i, $m := .Messages }}{{ $m.Content }}{{ end }}", []string{"Messages"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.template, func(t *testing.T) {
+			fields, err := TemplateFields(c.template)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, fields)
+		})
+	}
+
+	_, err := TemplateFields("{{ .Prompt ")
+	assert.Error(t, err)
+}
+
+func TestValidateTemplate(t *testing.T) {
+	var cases = []struct {
+		template string
+		err      error
+	}{
+		{"{{ if .System }}<|system|>{{ .System }}{{ end }}<|user|>{{ .Prompt }}<|assistant|>{{ .Response }}", nil},
+		{`{{ define "system" }}<|system|>{{ . }}{{ end }}{{ template "system" .System }}{{ .Prompt }}`, nil},
+		{`{{ define "loop" }}{{ template "loop" . }}{{ end }}{{ template "loop" . }}`, errTemplateRecursion},
+		{`{{ define "a" }}{{ if . }}{{ template "b" . }}{{ end }}{{ end }}{{ define "b" }}{{ template "a" . }}{{ end }}{{ template "a" .Prompt }}`, errTemplateRecursion},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			assert.ErrorIs(t, ValidateTemplate(c.template), c.err)
+		})
+	}
+
+	assert.EqualError(t, ValidateTemplate(`{{ define "loop" }}{{ template "loop" . }}{{ end }}{{ template "loop" . }}`), "template appears to recurse: loop -> loop")
+	assert.Error(t, ValidateTemplate("{{ .Prompt "))
+	assert.Error(t, ValidateTemplate(`{{ template "missing" . }}`))
+}
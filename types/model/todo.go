@@ -0,0 +1,40 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// todoMarkers are the comment markers reported by Todos.
+var todoMarkers = []string{"TODO:", "FIXME:"}
+
+// Todos reports comments containing a TODO: or FIXME: marker as info
+// diagnostics. cmds must keep comments, e.g. as returned by
+// ParseWithComments. Lines are those of the canonical form of cmds, as
+// produced by File.String.
+func Todos(cmds []Command) []Diagnostic {
+	var diags []Diagnostic
+
+	line := 1
+	for _, cmd := range cmds {
+		if cmd.Name == "comment" {
+			i := -1
+			for _, marker := range todoMarkers {
+				if j := strings.Index(cmd.Args, marker); j >= 0 && (i < 0 || j < i) {
+					i = j
+				}
+			}
+
+			if i >= 0 {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityInfo,
+					Message:  fmt.Sprintf("line %d: %s", line, cmd.Args[i:]),
+				})
+			}
+		}
+
+		line += strings.Count(cmd.String(), "\n") + 1
+	}
+
+	return diags
+}
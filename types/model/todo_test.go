@@ -0,0 +1,26 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodos(t *testing.T) {
+	input := `FROM llama3
+# TODO: raise num_ctx once the adapter supports it
+PARAMETER num_ctx 2048
+SYSTEM """You are
+a file parser."""
+# a plain comment
+PARAMETER temperature 0.7 # FIXME: tune for the new data set
+`
+
+	cmds, err := ParseWithComments(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Diagnostic{
+		{Severity: SeverityInfo, Message: "line 2: TODO: raise num_ctx once the adapter supports it"},
+		{Severity: SeverityInfo, Message: "line 8: FIXME: tune for the new data set"},
+	}, Todos(cmds))
+}
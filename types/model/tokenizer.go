@@ -0,0 +1,53 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var errBlobRefRequired = errors.New("file must be uploaded and referenced by digest, e.g. @sha256:<digest>")
+
+// Tokenizer returns the TOKENIZER override declared in cmds. The value is
+// either a reference to a file, written @path, or the override inline.
+func Tokenizer(cmds []Command) (string, bool) {
+	value, ok := "", false
+	for _, cmd := range cmds {
+		if cmd.Name == "tokenizer" {
+			value, ok = cmd.Args, true
+		}
+	}
+
+	return value, ok
+}
+
+// checkFileRef checks that the file referenced by an @path value exists
+// relative to baseDir.
+func checkFileRef(ref, baseDir string) error {
+	path, ok := localPath(ref, baseDir)
+	if !ok {
+		return nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.TrimPrefix(ref, "@"), err)
+	}
+
+	if fi.IsDir() {
+		return fmt.Errorf("%s: is a directory", strings.TrimPrefix(ref, "@"))
+	}
+
+	return nil
+}
+
+// checkBlobRef checks that an @ value references an uploaded blob by its
+// digest. baseDir is unused; it matches the signature of checkFileRef.
+func checkBlobRef(ref, _ string) error {
+	if _, err := ParseDigest(strings.TrimPrefix(ref, "@")); err != nil {
+		return fmt.Errorf("%w: %s", errBlobRefRequired, strings.TrimPrefix(ref, "@"))
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileTokenizer(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tokenizer.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("file", func(t *testing.T) {
+		modelfile, err := ParseFileWithOptions(strings.NewReader("FROM foo\nTOKENIZER @tokenizer.json"), ParseOptions{BaseDir: dir})
+		assert.NoError(t, err)
+		assert.Equal(t, []Command{
+			{Name: "model", Args: "foo"},
+			{Name: "tokenizer", Args: "@tokenizer.json"},
+		}, modelfile.Commands)
+
+		tokenizer, ok := Tokenizer(modelfile.Commands)
+		assert.True(t, ok)
+		assert.Equal(t, "@tokenizer.json", tokenizer)
+
+		assert.Equal(t, "FROM foo\nTOKENIZER @tokenizer.json\n", modelfile.String())
+	})
+
+	t.Run("inline", func(t *testing.T) {
+		modelfile, err := ParseFile(strings.NewReader("FROM foo\nTOKENIZER \"\"\"\n{\"chat_template\": \"{{ .Prompt }}\"}\n\"\"\""))
+		assert.NoError(t, err)
+
+		tokenizer, ok := Tokenizer(modelfile.Commands)
+		assert.True(t, ok)
+		assert.Equal(t, "\n{\"chat_template\": \"{{ .Prompt }}\"}\n", tokenizer)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := ParseFileWithOptions(strings.NewReader("FROM foo\nTOKENIZER @missing.json"), ParseOptions{BaseDir: dir})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("blob refs", func(t *testing.T) {
+		digest := "sha256:" + strings.Repeat("a", 64)
+		modelfile, err := ParseFileWithOptions(strings.NewReader("FROM foo\nTOKENIZER @"+digest), ParseOptions{RequireBlobRefs: true})
+		assert.NoError(t, err)
+
+		tokenizer, _ := Tokenizer(modelfile.Commands)
+		assert.Equal(t, "@"+digest, tokenizer)
+
+		// the file exists, but blob refs never read the file system
+		_, err = ParseFileWithOptions(strings.NewReader("FROM foo\nTOKENIZER @tokenizer.json"), ParseOptions{BaseDir: dir, RequireBlobRefs: true})
+		assert.ErrorIs(t, err, errBlobRefRequired)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		_, ok := Tokenizer([]Command{{Name: "model", Args: "foo"}})
+		assert.False(t, ok)
+	})
+}
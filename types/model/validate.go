@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	errAssistantBeforeUser  = errors.New("assistant message before any user message")
+	errConflictingParameter = errors.New("conflicting parameter values")
+)
+
+// Validate checks cmds against rules which span several commands: there must
+// be exactly one FROM, or a set of conditional FROMs of which at most one can
+// apply on any platform, the seeded conversation must not have an assistant
+// message before the first user message, and a scalar parameter must not be
+// set to different values. All violations are returned, joined with
+// errors.Join. Conditional FROMs alongside an unconditional one override it
+// where they apply, see Select, so are not counted. Other commands with
+// constraints and those scoped to a VARIANT are ignored, as they are expected
+// to repeat parameters.
+//
+// Validate is separate from parsing so that callers opt in to the checks.
+func Validate(cmds []Command) error {
+	var errs []error
+
+	var froms int
+	var conditional [][]Constraint
+	var user bool
+	values := make(map[string]string)
+	for _, cmd := range cmds {
+		if cmd.Name == "variant" {
+			break
+		}
+
+		switch {
+		case cmd.Name == "model":
+			if len(cmd.Constraints) == 0 {
+				froms++
+			} else {
+				conditional = append(conditional, cmd.Constraints)
+			}
+		case cmd.Name == "message":
+			role, _, _ := strings.Cut(cmd.Args, ": ")
+			switch role {
+			case "user":
+				user = true
+			case "assistant":
+				if !user {
+					errs = append(errs, errAssistantBeforeUser)
+					user = true
+				}
+			}
+		case cmd.isParameter():
+			if parameterKinds[cmd.Name] == reflect.Slice || len(cmd.Constraints) > 0 {
+				continue
+			}
+
+			if v, ok := values[cmd.Name]; ok && v != cmd.Args {
+				errs = append(errs, fmt.Errorf("%w: %s is set to %q and %q", errConflictingParameter, cmd.Name, v, cmd.Args))
+			}
+
+			values[cmd.Name] = cmd.Args
+		}
+	}
+
+	if froms == 0 && len(conditional) > 0 {
+		froms = 1
+		for i := range conditional {
+			for j := range i {
+				if !exclusive(conditional[i], conditional[j]) {
+					errs = append(errs, fmt.Errorf("%w: %s and %s can both apply", errMultipleFrom, formatConstraints(conditional[j])[1:], formatConstraints(conditional[i])[1:]))
+				}
+			}
+		}
+	}
+
+	switch {
+	case froms == 0:
+		errs = append(errs, ErrMissingFrom)
+	case froms > 1:
+		errs = append(errs, fmt.Errorf("%w: found %d", errMultipleFrom, froms))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected []error
+	}{
+		{
+			"FROM llama3\nPARAMETER temperature 0.5\nPARAMETER stop a\nPARAMETER stop b\nMESSAGE system hi\nMESSAGE user hello\nMESSAGE assistant hey",
+			nil,
+		},
+		{
+			"PARAMETER temperature 0.5",
+			[]error{ErrMissingFrom},
+		},
+		{
+			"FROM llama3\nFROM mistral",
+			[]error{errMultipleFrom},
+		},
+		{
+			"FROM llama3\nFROM llama3 [os=linux]",
+			nil,
+		},
+		{
+			"FROM llama3:q4_0 [mem<8G]\nFROM llama3:q8_0 [mem>=8G]",
+			nil,
+		},
+		{
+			"FROM llama3 [os=linux]\nFROM llama3 [os=darwin]\nFROM llama3 [os!=linux, os!=darwin]",
+			nil,
+		},
+		{
+			"FROM llama3:q4_0 [mem<=8G]\nFROM llama3:q8_0 [mem>=8G]",
+			[]error{errMultipleFrom},
+		},
+		{
+			"FROM llama3 [os=linux]\nFROM llama3 [arch=arm64]",
+			[]error{errMultipleFrom},
+		},
+		{
+			"FROM llama3\nMESSAGE assistant hey\nMESSAGE user hello\nMESSAGE assistant hi",
+			[]error{errAssistantBeforeUser},
+		},
+		{
+			"FROM llama3\nPARAMETER temperature 0.5\nPARAMETER temperature 0.7",
+			[]error{errConflictingParameter},
+		},
+		{
+			"FROM llama3\nPARAMETER temperature 0.5\nPARAMETER temperature 0.5",
+			nil,
+		},
+		{
+			"FROM llama3\nPARAMETER temperature 0.5\nVARIANT small\nPARAMETER temperature 0.7",
+			nil,
+		},
+		{
+			"MESSAGE assistant hey\nPARAMETER seed 1\nPARAMETER seed 2",
+			[]error{errAssistantBeforeUser, errConflictingParameter, ErrMissingFrom},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFileWithOptions(strings.NewReader(c.input), ParseOptions{AllowNoFrom: true, trivia: true})
+			assert.NoError(t, err)
+
+			err = Validate(modelfile.Commands)
+			if c.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			for _, expected := range c.expected {
+				assert.ErrorIs(t, err, expected)
+			}
+
+			assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), len(c.expected))
+		})
+	}
+}
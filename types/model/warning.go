@@ -0,0 +1,30 @@
+package model
+
+import (
+	"io"
+	"strconv"
+)
+
+// Warning is a problem found while parsing which does not fail the parse,
+// such as an unknown parameter name.
+type Warning struct {
+	Message string
+	// Line is the 1-based line the command starts on.
+	Line int
+}
+
+func (w Warning) String() string {
+	return "line " + strconv.Itoa(w.Line) + ": " + w.Message
+}
+
+// ParseWithWarnings is like ParseFile but also returns warnings for likely
+// mistakes, such as PARAMETER names which are not known options and so have
+// no effect. Commands are returned regardless of warnings.
+func ParseWithWarnings(r io.Reader) ([]Command, []Warning, error) {
+	f, err := ParseFileWithOptions(r, ParseOptions{warnings: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return f.Commands, f.warnings, nil
+}
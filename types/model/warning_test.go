@@ -0,0 +1,26 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWithWarnings(t *testing.T) {
+	input := `FROM foo
+PARAMETER temperature 0.7
+
+PARAMETER temperatrue 0.8
+PARAMETER preset creative
+`
+
+	cmds, warnings, err := ParseWithWarnings(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Warning{{Message: `unknown parameter "temperatrue"`, Line: 4}}, warnings)
+	assert.Contains(t, cmds, Command{Name: "temperatrue", Args: "0.8"})
+
+	_, warnings, err = ParseWithWarnings(strings.NewReader("FROM foo\nPARAMETER num_ctx 4096\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var errFromNotWritten = errors.New("FROM must be written before any other command")
+
+// Write writes cmds to w as a Modelfile in canonical form, the inverse of
+// ParseFile. Multiline values are written in triple quotes.
+func Write(w io.Writer, cmds []Command) error {
+	for _, cmd := range cmds {
+		if _, err := fmt.Fprintln(w, cmd.format(quoteBlock)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Writer writes Modelfile commands to an io.Writer one at a time. It is the
+// streaming counterpart of File.String.
+type Writer struct {
+	w    io.Writer
+	from bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteCommand writes cmd. The first command written must be a FROM.
+func (w *Writer) WriteCommand(cmd Command) error {
+	if cmd.Name == "model" {
+		w.from = true
+	} else if !w.from {
+		return fmt.Errorf("%w: %s", errFromNotWritten, cmd.Name)
+	}
+
+	_, err := fmt.Fprintln(w.w, cmd.String())
+	return err
+}
+
+func (w *Writer) WriteFrom(model string) error {
+	return w.WriteCommand(Command{Name: "model", Args: model})
+}
+
+func (w *Writer) WriteParameter(name, value string) error {
+	return w.WriteCommand(Command{Name: name, Args: value})
+}
+
+func (w *Writer) WriteTemplate(template string) error {
+	return w.WriteCommand(Command{Name: "template", Args: template})
+}
+
+func (w *Writer) WriteMessage(role, content string) error {
+	if !isValidMessageRole(role) {
+		return ErrInvalidRole
+	}
+
+	return w.WriteCommand(Command{Name: "message", Args: role + ": " + content})
+}
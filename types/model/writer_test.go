@@ -0,0 +1,119 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	assert.NoError(t, w.WriteFrom("foo"))
+	assert.NoError(t, w.WriteParameter("temperature", "0.7"))
+	assert.NoError(t, w.WriteParameter("stop", "### User:"))
+	assert.NoError(t, w.WriteTemplate("{{ .System }}\n{{ .Prompt }}\\"))
+	assert.NoError(t, w.WriteCommand(Command{Name: "system", Args: `You are a "file" parser.`}))
+	assert.NoError(t, w.WriteMessage("user", "Hey there!"))
+	assert.NoError(t, w.WriteMessage("assistant", "Hello!\nI want to parse all the things."))
+	assert.ErrorIs(t, w.WriteMessage("moderator", "Hello!"), ErrInvalidRole)
+
+	modelfile, err := ParseFile(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Name: "model", Args: "foo"},
+		{Name: "temperature", Args: "0.7"},
+		{Name: "stop", Args: "### User:"},
+		{Name: "template", Args: "{{ .System }}\n{{ .Prompt }}\\"},
+		{Name: "system", Args: `You are a "file" parser.`},
+		{Name: "message", Args: "user: Hey there!"},
+		{Name: "message", Args: "assistant: Hello!\nI want to parse all the things."},
+	}, modelfile.Commands)
+}
+
+func TestWriterFromFirst(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	assert.ErrorIs(t, w.WriteParameter("temperature", "0.7"), errFromNotWritten)
+	assert.Empty(t, b.String())
+}
+
+func TestWrite(t *testing.T) {
+	var cases = []string{
+		`
+FROM model1
+ADAPTER adapter1
+LICENSE MIT
+PARAMETER param1 value1
+PARAMETER param2 value2
+TEMPLATE template1
+`,
+		`
+FROM foo
+PARAMETER stop ### User:
+PARAMETER stop ### Assistant:
+PARAMETER penalize_newline true
+`,
+		`
+FROM foo
+SYSTEM """
+This is a
+multiline system.
+"""
+`,
+		`
+FROM foo
+TEMPLATE """{{ if .System }}<|start_header_id|>system<|end_header_id|>
+
+{{ .System }}<|eot_id|>{{ end }}"""
+`,
+		`
+FROM foo
+MESSAGE system You are a file parser. Always parse things.
+MESSAGE user Hey there!
+MESSAGE assistant "Hello, I want to parse all the things!
+"
+MESSAGE user[weight=2] """Say "hi"
+twice"""
+`,
+		`
+FROM foo
+SYSTEM You are a "file" parser.
+PARAMETER stop " "
+PARAMETER stop '"'
+LICENSE "  padded  "
+`,
+		`
+# @deprecated use bar
+FROM foo [mem<8G]
+PARAMETER num_gpu 0 [os=darwin,arch=arm64]
+ADAPTER name=style ./style.gguf 0.5
+VARIANT creative
+PARAMETER temperature 1.2
+`,
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			modelfile, err := ParseFile(strings.NewReader(c))
+			assert.NoError(t, err)
+
+			var b bytes.Buffer
+			assert.NoError(t, Write(&b, modelfile.Commands))
+
+			written, err := ParseFile(&b)
+			assert.NoError(t, err)
+			assert.Equal(t, modelfile.Commands, written.Commands)
+		})
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, Write(&b, []Command{
+		{Name: "model", Args: "foo"},
+		{Name: "template", Args: "{{ .System }}\n{{ .Prompt }}"},
+		{Name: "message", Args: "user: Hey there!"},
+	}))
+	assert.Equal(t, "FROM foo\nTEMPLATE \"\"\"{{ .System }}\n{{ .Prompt }}\"\"\"\nMESSAGE user Hey there!\n", b.String())
+}